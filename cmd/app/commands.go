@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sso-service/internal/app"
+	"sso-service/internal/config"
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Використання: app [--config=path] <команда> [аргументи]
+
+Команди:
+  serve                                       запустити сервер (за замовчуванням)
+  migrate up                                  застосувати нові міграції
+  migrate down [--steps=N]                    відкотити N останніх міграцій (1)
+  migrate status                              показати стан міграцій
+  user create --login --email [--admin] ...   створити користувача, пароль читається з stdin
+  user set-password (--email | --id)          встановити пароль, новий пароль читається з stdin
+  user lock (--email | --id) --reason [--until=RFC3339]
+                                              заблокувати користувача і відкликати його сесії
+  user unlock (--email | --id)                розблокувати користувача
+  user normalize                              привести збережені логіни й email до канонічного вигляду (NFKC)
+  sessions revoke --user=(email | id)         відкликати всі сесії користувача
+  keys generate                               створити перший ключ підпису токенів
+  keys rotate                                 створити новий ключ підпису і видалити ключі, виведені з обігу
+`
+
+// errUsage — неправильні аргументи; команда завершується з кодом 2.
+var errUsage = errors.New("invalid usage")
+
+type commandFunc func(ctx context.Context, cfg *config.Config, args []string) error
+
+var commands = map[string]map[string]commandFunc{
+	"migrate": {
+		"up":     migrateUp,
+		"down":   migrateDown,
+		"status": migrateStatus,
+	},
+	"user": {
+		"create":       userCreate,
+		"set-password": userSetPassword,
+		"lock":         userLock,
+		"unlock":       userUnlock,
+		"normalize":    userNormalize,
+	},
+	"sessions": {
+		"revoke": sessionsRevoke,
+	},
+	"keys": {
+		"generate": keysGenerate,
+		"rotate":   keysRotate,
+	},
+}
+
+func runCommand(cfg *config.Config, args []string) int {
+	if len(args) == 0 || args[0] == "serve" {
+		app.Run(cfg)
+		return 0
+	}
+	if args[0] == "help" {
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	}
+
+	group, ok := commands[args[0]]
+	if !ok || len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	run, ok := group[args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	err := run(context.Background(), cfg, args[2:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(os.Stderr, err.Error())
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	default:
+		fmt.Fprintln(os.Stderr, "Помилка:", err.Error())
+		return 1
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %v", errUsage, fs.Args())
+	}
+	return nil
+}
+
+func migrateUp(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := parseFlags(newFlagSet("migrate up"), args); err != nil {
+		return err
+	}
+
+	migrator, err := app.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Застосовано міграцій: %d, версія схеми: %d\n", applied, migrator.Latest())
+	return nil
+}
+
+func migrateDown(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("migrate down")
+	steps := fs.Int("steps", 1, "кількість міграцій для відкату")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *steps <= 0 {
+		return fmt.Errorf("%w: --steps must be positive", errUsage)
+	}
+
+	migrator, err := app.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	reverted, err := migrator.Down(ctx, *steps)
+	if err != nil {
+		return err
+	}
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Відкочено міграцій: %d, версія схеми: %d\n", reverted, version)
+	return nil
+}
+
+func migrateStatus(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := parseFlags(newFlagSet("migrate status"), args); err != nil {
+		return err
+	}
+
+	migrator, err := app.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "—"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return tw.Flush()
+}
+
+func userCreate(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("user create")
+	var req domain.RegisterRequest
+	fs.StringVar(&req.Login, "login", "", "логін")
+	fs.StringVar(&req.Email, "email", "", "email")
+	fs.StringVar(&req.FirstName, "first-name", "", "ім'я")
+	fs.StringVar(&req.LastName, "last-name", "", "прізвище")
+	fs.StringVar(&req.Phonenumber, "phone", "", "номер телефону")
+	fs.StringVar(&req.Address, "address", "", "адреса")
+	admin := fs.Bool("admin", false, "створити адміністратора")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if req.Login == "" || req.Email == "" {
+		return fmt.Errorf("%w: --login and --email are required", errUsage)
+	}
+
+	password, err := readPassword(os.Stdin)
+	if err != nil {
+		return err
+	}
+	req.Password = password
+
+	env, err := app.NewCLIEnv(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	if *admin {
+		err = env.Users.CreateAdmin(ctx, &req)
+	} else {
+		err = env.Users.CreateUser(ctx, &req)
+	}
+	if err != nil {
+		return err
+	}
+
+	env.RecordAudit(ctx, domain.AuditRegistration, req.UserID, map[string]any{"role": req.Role})
+
+	fmt.Printf("Створено користувача %s (id %d, роль %s)\n", req.Login, req.UserID, req.Role)
+	return nil
+}
+
+func userSetPassword(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("user set-password")
+	email, id := userFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	env, err := app.NewCLIEnv(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	user, err := env.FindUser(ctx, *email, *id)
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if err := env.Users.SetPassword(ctx, user.UserID, password); err != nil {
+		return err
+	}
+
+	env.RecordAudit(ctx, domain.AuditPasswordChange, user.UserID, nil)
+	// Як і після скидання через лист, старі сесії більше не повинні діяти.
+	if err := env.RevokeSessions(ctx, user.UserID, "password_set"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Пароль користувача %s змінено, сесії відкликано\n", user.Login)
+	return nil
+}
+
+func userLock(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("user lock")
+	email, id := userFlags(fs)
+	reason := fs.String("reason", "", "причина блокування")
+	untilStr := fs.String("until", "", "кінець блокування (RFC3339), порожньо — безстроково")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*reason) == "" {
+		return fmt.Errorf("%w: --reason is required", errUsage)
+	}
+
+	var until *time.Time
+	if *untilStr != "" {
+		t, err := time.Parse(time.RFC3339, *untilStr)
+		if err != nil {
+			return fmt.Errorf("%w: invalid --until: %v", errUsage, err)
+		}
+		until = &t
+	}
+
+	env, err := app.NewCLIEnv(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	user, err := env.FindUser(ctx, *email, *id)
+	if err != nil {
+		return err
+	}
+	if err := env.Users.Suspend(ctx, 0, user.UserID, *reason, until); err != nil {
+		return err
+	}
+
+	details := map[string]any{"reason": *reason}
+	if until != nil {
+		details["until"] = until.Format(time.RFC3339)
+	}
+	env.RecordAudit(ctx, domain.AuditAdminUserSuspended, user.UserID, details)
+	if err := env.RevokeSessions(ctx, user.UserID, "suspended"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Користувача %s заблоковано\n", user.Login)
+	return nil
+}
+
+func userUnlock(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("user unlock")
+	email, id := userFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	env, err := app.NewCLIEnv(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	user, err := env.FindUser(ctx, *email, *id)
+	if err != nil {
+		return err
+	}
+	if err := env.Users.Unsuspend(ctx, user.UserID); err != nil {
+		return err
+	}
+
+	env.RecordAudit(ctx, domain.AuditAdminUserUnsuspended, user.UserID, nil)
+
+	fmt.Printf("Користувача %s розблоковано\n", user.Login)
+	return nil
+}
+
+// userNormalize дозастосовує NFKC до логінів і email, збережених до появи цієї нормалізації.
+// Команду безпечно запускати повторно: вже нормалізовані записи не змінюються.
+func userNormalize(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := parseFlags(newFlagSet("user normalize"), args); err != nil {
+		return err
+	}
+
+	env, err := app.NewCLIEnv(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	updated, conflicts, err := env.NormalizeIdentifiers(ctx)
+	fmt.Printf("Нормалізовано облікових записів: %d\n", len(updated))
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("accounts %v collide with existing ones after normalization and must be merged manually", conflicts)
+	}
+	return nil
+}
+
+func sessionsRevoke(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := newFlagSet("sessions revoke")
+	userRef := fs.String("user", "", "email або id користувача")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *userRef == "" {
+		return fmt.Errorf("%w: --user is required", errUsage)
+	}
+
+	env, err := app.NewCLIEnv(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	email := *userRef
+	id, err := strconv.Atoi(*userRef)
+	if err == nil {
+		email = ""
+	}
+	user, err := env.FindUser(ctx, email, id)
+	if err != nil {
+		return err
+	}
+	if err := env.RevokeSessions(ctx, user.UserID, "cli"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Сесії користувача %s відкликано\n", user.Login)
+	return nil
+}
+
+// keysGenerate створює перший ключ. Сервіс сам ключів не генерує, щоб репліки зі спільним
+// каталогом не створили кожна свій.
+func keysGenerate(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := parseFlags(newFlagSet("keys generate"), args); err != nil {
+		return err
+	}
+
+	_, err := auth.LoadKeySet(cfg.SigningKeysDir, cfg.SigningKeyRetention)
+	if err == nil {
+		return fmt.Errorf("%s already contains signing keys, use \"keys rotate\" to add a new one", cfg.SigningKeysDir)
+	}
+	if !errors.Is(err, auth.ErrNoSigningKeys) {
+		return err
+	}
+
+	key, err := auth.GenerateSigningKey(cfg.SigningKeysDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Створено ключ %s у %s\n", key.ID, cfg.SigningKeysDir)
+	return nil
+}
+
+func keysRotate(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := parseFlags(newFlagSet("keys rotate"), args); err != nil {
+		return err
+	}
+
+	// Попередні ключі лишаються в JWKS ще signing_key_retention, тож видані ними токени діють до закінчення.
+	key, err := auth.GenerateSigningKey(cfg.SigningKeysDir)
+	if err != nil {
+		return err
+	}
+
+	removed, err := auth.PruneSigningKeys(cfg.SigningKeysDir, cfg.SigningKeyRetention)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Створено ключ %s у %s. Перезапустіть сервіс, щоб підписувати ним нові токени.\n", key.ID, cfg.SigningKeysDir)
+	for _, id := range removed {
+		fmt.Printf("Видалено ключ %s, виведений з обігу\n", id)
+	}
+	return nil
+}
+
+func userFlags(fs *flag.FlagSet) (*string, *int) {
+	email := fs.String("email", "", "email користувача")
+	id := fs.Int("id", 0, "id користувача")
+	return email, id
+}
+
+// readPassword читає пароль з першого рядка stdin, щоб він не потрапив в історію shell.
+func readPassword(r io.Reader) (string, error) {
+	fmt.Fprint(os.Stderr, "Пароль: ")
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%w: password must not be empty", errUsage)
+	}
+	return password, nil
+}
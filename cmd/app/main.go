@@ -1,9 +1,9 @@
 package main
 
 import (
+	"flag"
 	"log/slog"
 	"os"
-	"sso-service/internal/app"
 	"sso-service/internal/config"
 	"sso-service/internal/lib/logger"
 )
@@ -13,5 +13,6 @@ func main() {
 
 	logger.InitGlobalLogger(os.Stdout, slog.LevelDebug)
 
-	app.Run(config)
+	// Без підкоманди запускається сервер, як і раніше.
+	os.Exit(runCommand(config, flag.Args()))
 }
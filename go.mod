@@ -2,7 +2,21 @@ module sso-service
 
 go 1.24.0
 
-require github.com/fatih/color v1.18.0
+require (
+	github.com/coreos/go-oidc/v3 v3.14.1
+	github.com/crewjam/saml v0.5.1
+	github.com/fatih/color v1.18.0
+	golang.org/x/oauth2 v0.30.0
+)
+
+require (
+	github.com/beevik/etree v1.5.0 // indirect
+	github.com/go-jose/go-jose/v4 v4.0.5 // indirect
+	github.com/golang-jwt/jwt/v4 v4.5.2 // indirect
+	github.com/jonboulle/clockwork v0.2.2 // indirect
+	github.com/mattermost/xml-roundtrip-validator v0.1.0 // indirect
+	github.com/russellhaering/goxmldsig v1.4.0 // indirect
+)
 
 require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
@@ -15,5 +29,6 @@ require (
 	github.com/rs/cors v1.11.1
 	golang.org/x/crypto v0.37.0
 	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/text v0.24.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sso-service/internal/config"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/mailer"
+	"sso-service/internal/migrations"
+	"sso-service/internal/repository"
+	"sso-service/internal/service"
+	"sso-service/pkg/database"
+)
+
+// cliUserAgent позначає в журналі аудиту дії, виконані з командного рядка.
+const cliUserAgent = "sso-service-cli"
+
+// NewMigrator підключається до бази з конфігурації для команд migrate.
+func NewMigrator(cfg *config.Config) (*migrations.Migrator, error) {
+	db := database.NewPostgresConnection(cfg.DB.Host, cfg.DB.DBName, cfg.DB.User, cfg.DB.Password)
+	if db == nil {
+		return nil, fmt.Errorf("could not connect to database")
+	}
+	return migrations.New(db)
+}
+
+// CLIEnv — сервіси для адміністративних команд, зібрані так само, як у Run,
+// щоб CLI поводився однаково з HTTP API.
+type CLIEnv struct {
+	db       *sql.DB
+	users    *repository.PostgresUserRepo
+	Users    *service.UsersService
+	Sessions *service.SessionsService
+	Audit    *service.AuditService
+}
+
+func NewCLIEnv(ctx context.Context, cfg *config.Config) (*CLIEnv, error) {
+	db := database.NewPostgresConnection(cfg.DB.Host, cfg.DB.DBName, cfg.DB.User, cfg.DB.Password)
+	if db == nil {
+		return nil, fmt.Errorf("could not connect to database")
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.CheckVersion(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	repo := repository.NewPostgresUserRepo(db)
+	notificationsService := service.NewNotificationsService(mailer.LogMailer{}, cfg.PublicURL)
+	sessions := service.NewSessionsService(repository.NewPostgresSessionRepo(db), repository.NewPostgresUsedTokenRepo(db), cfg.SessionMaxAge)
+
+	return &CLIEnv{
+		db:       db,
+		users:    repo,
+		Users:    service.NewUsersService(repo, notificationsService, cfg.StorageURL),
+		Sessions: sessions,
+		Audit:    service.NewAuditService(repository.NewPostgresAuditRepo(db)),
+	}, nil
+}
+
+func (e *CLIEnv) Close() error {
+	return e.db.Close()
+}
+
+// FindUser шукає користувача за id, якщо він заданий, інакше за email.
+func (e *CLIEnv) FindUser(ctx context.Context, email string, id int) (domain.User, error) {
+	switch {
+	case id != 0 && email != "":
+		return domain.User{}, errors.New("specify either email or id, not both")
+	case id != 0:
+		return e.Users.GetByID(ctx, id)
+	case email != "":
+		return e.Users.GetByEmail(ctx, email)
+	default:
+		return domain.User{}, errors.New("user email or id is required")
+	}
+}
+
+// NormalizeIdentifiers зводить збережені логіни й email до вигляду domain.NormalizeLogin/NormalizeEmail.
+// Міграція 0010 нормалізувала їх лише через LOWER(TRIM()), без NFKC, тож такі записи не знаходяться
+// пошуком за нормалізованим значенням. Запис, що після нормалізації збігся б з іншим, не змінюється
+// і повертається в conflicts — такі облікові записи треба об'єднати вручну.
+func (e *CLIEnv) NormalizeIdentifiers(ctx context.Context) (updated []int, conflicts []int, err error) {
+	users, err := e.users.ListIdentifiers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, u := range users {
+		login, email := domain.NormalizeLogin(u.Login), domain.NormalizeEmail(u.Email)
+		if login == u.Login && email == u.Email {
+			continue
+		}
+
+		err := e.users.UpdateIdentifiers(ctx, u.UserID, login, email)
+		switch {
+		case errors.Is(err, domain.ErrConflict):
+			conflicts = append(conflicts, u.UserID)
+		case err != nil:
+			return updated, conflicts, err
+		default:
+			updated = append(updated, u.UserID)
+		}
+	}
+
+	return updated, conflicts, nil
+}
+
+// RecordAudit записує подію без актора: оператор CLI не є користувачем сервісу.
+func (e *CLIEnv) RecordAudit(ctx context.Context, eventType domain.AuditEventType, targetID int, details map[string]any) {
+	event := domain.AuditEvent{
+		Type:      eventType,
+		UserAgent: cliUserAgent,
+		Details:   details,
+	}
+	if targetID != 0 {
+		event.TargetID = &targetID
+	}
+	e.Audit.Record(ctx, event)
+}
+
+func (e *CLIEnv) RevokeSessions(ctx context.Context, userID int, reason string) error {
+	if err := e.Sessions.RevokeAll(ctx, userID); err != nil {
+		slog.Error("Не вдалося відкликати сесії користувача", "user_id", userID, "err", err.Error())
+		return err
+	}
+	e.RecordAudit(ctx, domain.AuditTokenRevoked, userID, map[string]any{"all_sessions": true, "reason": reason})
+	return nil
+}
@@ -1,22 +1,159 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
 	"sso-service/internal/config"
 	"sso-service/internal/delivery/http_handlers"
+	"sso-service/internal/domain"
+	"sso-service/internal/federation"
+	"sso-service/internal/lib/clientip"
+	"sso-service/internal/lib/mailer"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/migrations"
 	"sso-service/internal/repository"
 	"sso-service/internal/server"
 	"sso-service/internal/service"
+	"sso-service/pkg/auth"
 	"sso-service/pkg/database"
 )
 
 func Run(cfg *config.Config) {
 	db := database.NewPostgresConnection(cfg.DB.Host, cfg.DB.DBName, cfg.DB.User, cfg.DB.Password)
 
+	migrator, err := migrations.New(db)
+	if err != nil {
+		panic("Failed to load migrations: " + err.Error())
+	}
+	if cfg.AutoMigrate {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			slog.Error("Помилка застосування міграцій", "err", err.Error())
+			os.Exit(1)
+		}
+		slog.Info("Міграції застосовано", "count", applied)
+	}
+	version, err := migrator.CheckVersion(context.Background())
+	var behind *migrations.ErrSchemaBehind
+	if errors.As(err, &behind) {
+		slog.Error("Схема бази даних застаріла, потрібно застосувати міграції", "version", behind.Current, "required", behind.Latest)
+		os.Exit(1)
+	}
+	if err != nil {
+		slog.Error("Не вдалося перевірити версію схеми", "err", err.Error())
+		os.Exit(1)
+	}
+	if version > migrator.Latest() {
+		slog.Warn("Схема бази даних новіша за цю збірку", "version", version, "latest", migrator.Latest())
+	}
+
 	repo := repository.NewPostgresUserRepo(db)
-	usersService := service.NewUsersService(repo, cfg.StorageURL)
-	usersHandler := http_handlers.NewUsersHandler(usersService, cfg.TokenTTL)
 
-	handler := server.NewRouter(usersHandler)
+	keys, err := auth.LoadKeySet(cfg.SigningKeysDir, cfg.SigningKeyRetention)
+	if errors.Is(err, auth.ErrNoSigningKeys) {
+		slog.Error("Немає ключа підпису токенів, створіть його командою \"app keys generate\"", "dir", cfg.SigningKeysDir)
+		os.Exit(1)
+	}
+	if err != nil {
+		panic("Failed to load signing keys: " + err.Error())
+	}
+	auth.UseKeySet(keys)
+
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if cfg.Mailer.Host != "" {
+		mail = mailer.NewSMTPMailer(cfg.Mailer.Host, cfg.Mailer.Port, cfg.Mailer.Username, cfg.Mailer.Password, cfg.Mailer.From)
+	}
+	notificationsService := service.NewNotificationsService(mail, cfg.PublicURL)
+
+	usersService := service.NewUsersService(repo, notificationsService, cfg.StorageURL)
+	auditService := service.NewAuditService(repository.NewPostgresAuditRepo(db))
+	usedTokens := repository.NewPostgresUsedTokenRepo(db)
+	sessionsService := service.NewSessionsService(repository.NewPostgresSessionRepo(db), usedTokens, cfg.SessionMaxAge)
+	devicesService := service.NewDevicesService(repository.NewPostgresDeviceRepo(db), usedTokens, notificationsService)
+
+	providers := federation.NewProviders(context.Background(), cfg.OAuthProviders, cfg.PublicURL)
+	samlPartners := federation.NewSAMLPartners(context.Background(), cfg.SAML, cfg.PublicURL)
+	federationService := service.NewFederationService(providers, samlPartners, repository.NewPostgresIdentityRepo(db),
+		repo, repository.NewPostgresOrganizationRepo(db), usedTokens)
+
+	clients := make([]domain.OAuthClient, 0, len(cfg.OAuthClients))
+	for id, client := range cfg.OAuthClients {
+		clients = append(clients, domain.OAuthClient{
+			ClientID:   id,
+			Name:       client.Name,
+			Secret:     client.Secret,
+			Public:     client.Public,
+			GrantTypes: client.GrantTypes,
+			Scopes:     client.Scopes,
+			Trusted:    client.Trusted,
+			Audiences:  client.Audiences,
+		})
+	}
+	clientsService := service.NewClientsService(clients)
+	consentService := service.NewConsentService(repository.NewPostgresConsentRepo(db), clientsService)
+	tokenExchangeService := service.NewTokenExchangeService(cfg.TokenExchangeTTL)
+	deviceFlowService := service.NewDeviceFlowService(repository.NewPostgresDeviceAuthRepo(db),
+		cfg.DeviceFlow.VerificationURL, cfg.DeviceFlow.CodeTTL, cfg.DeviceFlow.Interval)
+
+	accessTokensService := service.NewAccessTokensService(repository.NewPostgresAccessTokenRepo(db), repo)
+	auth.RegisterOpaqueTokenResolver(domain.PersonalTokenPrefix, accessTokensService.Resolve)
+
+	auth.RegisterTokenCheck(func(ctx context.Context, token *auth.JWTToken) error {
+		return usersService.CheckUserStatus(ctx, token.UserID)
+	})
+	// Токени без sid видані до появи сесій і діють до свого закінчення.
+	auth.RegisterTokenCheck(func(ctx context.Context, token *auth.JWTToken) error {
+		if token.SessionID == "" {
+			return nil
+		}
+		return sessionsService.Validate(ctx, token.SessionID, token.UserID)
+	})
+	auth.RegisterTokenCheck(consentService.CheckToken)
+	auth.RegisterErrorWriter(responseHTTP.AuthError)
+	auth.RegisterAuthenticatedHook(http_handlers.LogAuthenticatedUser)
+
+	if cfg.Cookies.Enabled {
+		auth.EnableCookieAuth()
+	}
+	cookieSessions := http_handlers.NewCookieSessions(http_handlers.CookieOptions{
+		Enabled:    cfg.Cookies.Enabled,
+		Domain:     cfg.Cookies.Domain,
+		Secure:     cfg.Cookies.SecureCookies(),
+		SameSite:   cfg.Cookies.SameSiteMode(),
+		RefreshTTL: cfg.Cookies.RefreshTTL,
+	}, cfg.TokenTTL)
+
+	usersHandler := http_handlers.NewUsersHandler(usersService, sessionsService, devicesService, auditService, cookieSessions,
+		cfg.TokenTTL, cfg.ImpersonationTTL)
+	auditHandler := http_handlers.NewAuditHandler(auditService)
+	sessionsHandler := http_handlers.NewSessionsHandler(sessionsService, auditService)
+	securityHandler := http_handlers.NewSecurityHandler(usersService, sessionsService, devicesService, auditService)
+	oauthHandler := http_handlers.NewOAuthHandler(federationService, usersService, sessionsService, auditService, cookieSessions,
+		cfg.TokenTTL)
+	oauthServerHandler := http_handlers.NewOAuthServerHandler(clientsService, deviceFlowService, consentService,
+		tokenExchangeService, usersService, sessionsService, auditService, cfg.TokenTTL)
+	accessTokensHandler := http_handlers.NewAccessTokensHandler(accessTokensService, auditService)
+	consentHandler := http_handlers.NewConsentHandler(consentService, auditService)
+
+	proxies, err := clientip.NewResolver(cfg.TrustedProxies)
+	if err != nil {
+		panic("Invalid trusted_proxies: " + err.Error())
+	}
+
+	handler := server.NewRouter(server.Handlers{
+		Users:        usersHandler,
+		Audit:        auditHandler,
+		Sessions:     sessionsHandler,
+		Security:     securityHandler,
+		OAuth:        oauthHandler,
+		OAuthServer:  oauthServerHandler,
+		AccessTokens: accessTokensHandler,
+		Consent:      consentHandler,
+		Keys:         http_handlers.NewKeysHandler(keys),
+		ForwardAuth:  http_handlers.NewForwardAuthHandler(service.NewForwardAuthService(cfg.ForwardAuthCacheTTL)),
+	}, auth.StepUpPolicy{MaxAge: cfg.StepUp.MaxAge, RequireMFA: cfg.StepUp.RequireMFA}, proxies)
 
 	server.StartServer(handler, cfg.Port, cfg.Timeout)
 }
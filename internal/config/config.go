@@ -2,7 +2,9 @@ package config
 
 import (
 	"flag"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +17,47 @@ type Config struct {
 	Port       string        `yaml:"port"`
 	Timeout    time.Duration `yaml:"timeout"`
 	StorageURL string        `yaml:"storage_service_url"`
+	PublicURL  string        `yaml:"public_url"`
+	// TrustedProxies — адреси або мережі CIDR проксі (API-шлюзу), яким можна вірити щодо
+	// X-Forwarded-For. Від інших клієнтів ці заголовки ігноруються.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// SigningKeysDir — каталог з RSA-ключами підпису токенів (<kid>.pem).
+	SigningKeysDir string `yaml:"signing_keys_dir"`
+	// SigningKeyRetention — скільки замінений ключ лишається в JWKS; не менше за найдовший строк
+	// токена чи посилання, підписаного ключем. За замовчуванням обчислюється з TTL.
+	SigningKeyRetention time.Duration `yaml:"signing_key_retention"`
+	Mailer              MailerConfig  `yaml:"mailer"`
+	// AutoMigrate застосовує нові міграції під час старту; інакше — лише "app migrate up", а сервіс перевіряє версію схеми.
+	AutoMigrate bool `yaml:"auto_migrate"`
+
+	// SessionMaxAge — абсолютний строк сесії входу: після нього токени не оновлюються, потрібен новий вхід.
+	SessionMaxAge time.Duration `yaml:"session_max_age"`
+	// ImpersonationTTL — час життя токена "act as" для підтримки.
+	ImpersonationTTL time.Duration `yaml:"impersonation_ttl"`
+	// ForwardAuthCacheTTL — скільки API-шлюз може покладатися на вже перевірений токен.
+	ForwardAuthCacheTTL time.Duration `yaml:"forward_auth_cache_ttl"`
+	// Cookies — браузерні сесії в HttpOnly cookie замість токена в localStorage.
+	Cookies CookiesConfig `yaml:"cookies"`
+	// StepUp — вимоги до свіжої автентифікації для чутливих дій.
+	StepUp StepUpConfig `yaml:"step_up"`
+	// TokenExchangeTTL — максимальний час життя токена, отриманого обміном (RFC 8693).
+	TokenExchangeTTL time.Duration `yaml:"token_exchange_ttl"`
+
+	OAuthProviders map[string]OAuthProviderConfig `yaml:"oauth_providers"`
+	SAML           SAMLConfig                     `yaml:"saml"`
+
+	OAuthClients map[string]OAuthClientConfig `yaml:"oauth_clients"`
+	DeviceFlow   DeviceFlowConfig             `yaml:"device_flow"`
+}
+
+// MailerConfig описує SMTP. Якщо Host порожній, листи лише логуються.
+// Пароль береться з SMTP_PASSWORD.
+type MailerConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"-"`
+	From     string `yaml:"from"`
 }
 
 type DBConfig struct {
@@ -24,6 +67,97 @@ type DBConfig struct {
 	Password string
 }
 
+// OAuthProviderConfig описує зовнішнього провайдера входу.
+// Для type "oidc" достатньо issuer — решта береться з discovery,
+// для "oauth2" (Facebook) потрібні auth_url, token_url та userinfo_url.
+// Секрет клієнта береться з OAUTH_<NAME>_CLIENT_SECRET.
+type OAuthProviderConfig struct {
+	Type         string   `yaml:"type"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"-"`
+	AuthURL      string   `yaml:"auth_url"`
+	TokenURL     string   `yaml:"token_url"`
+	UserInfoURL  string   `yaml:"userinfo_url"`
+	Scopes       []string `yaml:"scopes"`
+	ResponseMode string   `yaml:"response_mode"`
+	// TrustEmail вважає email підтвердженим, якщо провайдер не повертає email_verified.
+	TrustEmail bool `yaml:"trust_email"`
+}
+
+// SAMLConfig описує наш SAML service provider і IdP партнерів.
+// Ключ партнера використовується в URL (/api/sso/saml/{partner}/...) та як slug організації.
+type SAMLConfig struct {
+	CertPath string                       `yaml:"sp_cert_path"`
+	KeyPath  string                       `yaml:"sp_key_path"`
+	Partners map[string]SAMLPartnerConfig `yaml:"partners"`
+}
+
+type SAMLPartnerConfig struct {
+	Organization string `yaml:"organization"`
+	MetadataURL  string `yaml:"idp_metadata_url"`
+	MetadataPath string `yaml:"idp_metadata_path"`
+	// Attributes відображає поля користувача (login, email, first_name, last_name,
+	// phonenumber, address) на назви атрибутів у твердженні IdP.
+	Attributes map[string]string `yaml:"attributes"`
+	// AllowedDomains — домени email, які IdP партнера може підтверджувати; обов'язкові.
+	// Лише для них наявний користувач прив'язується за email або створюється новий.
+	AllowedDomains    []string `yaml:"allowed_domains"`
+	AllowIDPInitiated bool     `yaml:"allow_idp_initiated"`
+}
+
+// OAuthClientConfig описує застосунок, що отримує токени через /api/sso/token.
+// Секрет конфіденційного клієнта береться з OAUTH_CLIENT_<ID>_SECRET (дефіси замінюються на "_").
+type OAuthClientConfig struct {
+	Name       string   `yaml:"name"`
+	Secret     string   `yaml:"-"`
+	Public     bool     `yaml:"public"`
+	GrantTypes []string `yaml:"grant_types"`
+	// Scopes обмежує дозволи, які може запитувати клієнт; порожній — будь-які відомі.
+	Scopes []string `yaml:"scopes"`
+	// Trusted — власний застосунок CarVia, для якого екран згоди не показується.
+	Trusted bool `yaml:"trusted"`
+	// Audiences — сервіси, для яких клієнт може обміняти токен користувача.
+	Audiences []string `yaml:"audiences"`
+}
+
+type CookiesConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Domain  string `yaml:"domain"`
+	// Secure (за замовчуванням true) варто вимикати лише для локальної розробки по http.
+	Secure *bool `yaml:"secure"`
+	// SameSite: lax, strict або none.
+	SameSite   string        `yaml:"same_site"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+// SecureCookies — cookie лише для https, якщо secure не вимкнено явно.
+func (c CookiesConfig) SecureCookies() bool {
+	return c.Secure == nil || *c.Secure
+}
+
+func (c CookiesConfig) SameSiteMode() http.SameSite {
+	switch strings.ToLower(c.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+type StepUpConfig struct {
+	MaxAge     time.Duration `yaml:"max_age"`
+	RequireMFA bool          `yaml:"require_mfa"`
+}
+
+type DeviceFlowConfig struct {
+	VerificationURL string        `yaml:"verification_url"`
+	CodeTTL         time.Duration `yaml:"code_ttl"`
+	Interval        time.Duration `yaml:"interval"`
+}
+
 func MustLoadConfig() *Config {
 	path := fetchConfigPath()
 
@@ -43,6 +177,42 @@ func MustLoadConfig() *Config {
 	}
 
 	cfg.DB = getDBconfig()
+	if cfg.SigningKeysDir == "" {
+		cfg.SigningKeysDir = "./keys"
+	}
+	if cfg.SessionMaxAge == 0 {
+		cfg.SessionMaxAge = 30 * 24 * time.Hour
+	}
+	if cfg.ImpersonationTTL == 0 {
+		cfg.ImpersonationTTL = 15 * time.Minute
+	}
+	if cfg.ForwardAuthCacheTTL == 0 {
+		cfg.ForwardAuthCacheTTL = 30 * time.Second
+	}
+	if cfg.Cookies.RefreshTTL == 0 {
+		cfg.Cookies.RefreshTTL = 30 * 24 * time.Hour
+	}
+	if cfg.StepUp.MaxAge == 0 {
+		cfg.StepUp.MaxAge = 10 * time.Minute
+	}
+	if cfg.TokenExchangeTTL == 0 {
+		cfg.TokenExchangeTTL = 5 * time.Minute
+	}
+	if cfg.SigningKeyRetention == 0 {
+		// Токен доступу діє TokenTTL і ще годину, cookie оновлення — RefreshTTL, посилання з листів — до 7 днів.
+		cfg.SigningKeyRetention = max(cfg.TokenTTL+time.Hour, cfg.Cookies.RefreshTTL, 7*24*time.Hour)
+	}
+	cfg.Mailer.Password = os.Getenv("SMTP_PASSWORD")
+
+	for name, provider := range cfg.OAuthProviders {
+		provider.ClientSecret = os.Getenv("OAUTH_" + strings.ToUpper(name) + "_CLIENT_SECRET")
+		cfg.OAuthProviders[name] = provider
+	}
+
+	for id, client := range cfg.OAuthClients {
+		client.Secret = os.Getenv("OAUTH_CLIENT_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_SECRET")
+		cfg.OAuthClients[id] = client
+	}
 
 	return &cfg
 }
@@ -0,0 +1,114 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type AccessTokensHandler struct {
+	tokens *service.AccessTokensService
+	audit  *service.AuditService
+}
+
+func NewAccessTokensHandler(tokens *service.AccessTokensService, audit *service.AuditService) *AccessTokensHandler {
+	return &AccessTokensHandler{
+		tokens: tokens,
+		audit:  audit,
+	}
+}
+
+// interactiveUserID повертає користувача, автентифікованого інтерактивно. Персональним токеном
+// не можна керувати іншими токенами, щоб витік одного токена не давав випустити нові.
+// Так само токени сторонніх застосунків (з обмеженим scope) не керують згодами й токенами.
+func interactiveUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return 0, false
+	}
+
+	if token, ok := auth.TokenFromContext(r.Context()); ok && token.Delegated() {
+		message := i18n.ClientTokenNotAllowed
+		if token.AuthMethod == "pat" {
+			message = i18n.PersonalTokenNotAllowed
+		}
+		responseHTTP.JSONError(w, r, http.StatusForbidden, message)
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func (h *AccessTokensHandler) CreateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	plain, token, err := h.tokens.Create(r.Context(), userID, req)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка створення персонального токена", "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.TokenCreateFailed)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditAccessTokenCreated, userID, userID,
+		map[string]any{"token_id": token.ID, "name": token.Name, "scopes": token.Scopes})
+
+	responseHTTP.JSONResp(w, http.StatusCreated, domain.CreatedAccessTokenResponse{Token: plain, Info: token})
+}
+
+func (h *AccessTokensHandler) ListTokensHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.tokens.List(r.Context(), userID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні персональних токенів", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, tokens)
+}
+
+func (h *AccessTokensHandler) RevokeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidTokenID)
+		return
+	}
+
+	if err := h.tokens.Revoke(r.Context(), userID, tokenID); err != nil {
+		slog.DebugContext(r.Context(), "Помилка відкликання персонального токена", "token_id", tokenID, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.TokenNotFound)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditTokenRevoked, userID, userID, map[string]any{"token_id": tokenID})
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.TokenRevoked)
+}
@@ -0,0 +1,178 @@
+package http_handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+)
+
+func TestAccessTokens(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+	create := domain.CreateAccessTokenRequest{Name: "ci", Scopes: []string{domain.ScopeListingsRead}}
+
+	t.Run("RequiresRecentAuthentication", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/tokens", staleToken(t, u), create), http.StatusUnauthorized)
+	})
+
+	t.Run("UnknownScope", func(t *testing.T) {
+		bad := domain.CreateAccessTokenRequest{Name: "ci", Scopes: []string{"everything"}}
+		rec := call(t, http.MethodPost, "/api/sso/tokens", token, bad)
+		expectStatus(t, rec, http.StatusBadRequest)
+
+		resp := decode[responseHTTP.Problem](t, rec)
+		if resp.Code != responseHTTP.CodeValidation || len(resp.Errors) != 1 || resp.Errors[0].Field != "scopes" {
+			t.Errorf("error response = %+v, want validation error on scopes", resp)
+		}
+	})
+
+	rec := call(t, http.MethodPost, "/api/sso/tokens", token, create)
+	expectStatus(t, rec, http.StatusCreated)
+	created := decode[domain.CreatedAccessTokenResponse](t, rec)
+	if !strings.HasPrefix(created.Token, domain.PersonalTokenPrefix) || created.Info.Name != "ci" {
+		t.Fatalf("created token = %+v", created)
+	}
+
+	// Персональний токен допущено лише на маршрути з його дозволами і він не керує іншими токенами.
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", created.Token, nil), http.StatusForbidden)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/tokens", created.Token, nil), http.StatusForbidden)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/userinfo", created.Token, nil), http.StatusOK)
+
+	rec = call(t, http.MethodGet, "/api/sso/tokens", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	listed := decode[[]domain.PersonalAccessToken](t, rec)
+	if len(listed) != 1 || listed[0].ID != created.Info.ID {
+		t.Fatalf("listed tokens = %+v, want the created one", listed)
+	}
+
+	revoke := "/api/sso/tokens/" + strconv.Itoa(created.Info.ID)
+	t.Run("ForeignToken", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodDelete, revoke, login(t, newUser(t, "user")), nil), http.StatusNotFound)
+	})
+
+	expectStatus(t, call(t, http.MethodDelete, revoke, token, nil), http.StatusOK)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/userinfo", created.Token, nil), http.StatusUnauthorized)
+}
+
+// createToken випускає персональний токен зі scopes від імені власника token.
+func createToken(t *testing.T, token string, scopes ...string) string {
+	t.Helper()
+
+	rec := call(t, http.MethodPost, "/api/sso/tokens", token, domain.CreateAccessTokenRequest{Name: "integration", Scopes: scopes})
+	expectStatus(t, rec, http.StatusCreated)
+	return decode[domain.CreatedAccessTokenResponse](t, rec).Token
+}
+
+func TestPersonalTokenScopes(t *testing.T) {
+	t.Parallel()
+
+	admin := newUser(t, "admin")
+	pat := createToken(t, login(t, admin), domain.ScopeInventoryRead)
+	victim := newUser(t, "user")
+
+	routes := []struct{ method, path string }{
+		{http.MethodPut, "/api/sso/update_user_profile"},
+		{http.MethodPost, "/api/sso/refresh"},
+		{http.MethodPost, "/api/sso/reauthenticate"},
+		{http.MethodGet, "/api/sso/sessions"},
+		{http.MethodPost, "/api/sso/device/verify"},
+		{http.MethodPost, "/api/sso/admin/users/" + strconv.Itoa(victim.ID) + "/suspend"},
+		{http.MethodGet, "/api/sso/admin/audit"},
+	}
+	for _, route := range routes {
+		rec := call(t, route.method, route.path, pat, nil)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s with a scoped personal token: status = %d, want 403", route.method, route.path, rec.Code)
+		}
+	}
+
+	// Ролей власника токен не несе: сервіси, що перевіряють roles, не приймуть його за адміністратора.
+	req := newRequest(t, http.MethodPost, "/api/sso/introspect", url.Values{"token": {pat}})
+	req.SetBasicAuth("listing-service", exchangeSecret)
+	rec := serve(req)
+	expectStatus(t, rec, http.StatusOK)
+	if resp := decode[auth.IntrospectionResponse](t, rec); !resp.Active || len(resp.Roles) != 0 {
+		t.Errorf("introspection of a personal token = %+v, want active without roles", resp)
+	}
+
+	// Профіль відкрито персональним токенам, але лише з дозволом profile.
+	rec = call(t, http.MethodGet, "/api/sso/user_profile", pat, nil)
+	expectStatus(t, rec, http.StatusForbidden)
+	if challenge := rec.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, `error="insufficient_scope"`) {
+		t.Errorf("WWW-Authenticate = %q, want insufficient_scope", challenge)
+	}
+
+	profileToken := createToken(t, login(t, victim), domain.ScopeProfile)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", profileToken, nil), http.StatusOK)
+}
+
+type memoryAccessTokens struct {
+	mu     sync.Mutex
+	tokens []domain.PersonalAccessToken
+}
+
+func (r *memoryAccessTokens) CreateToken(ctx context.Context, token domain.PersonalAccessToken) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = len(r.tokens) + 1
+	r.tokens = append(r.tokens, token)
+	return token.ID, nil
+}
+
+func (r *memoryAccessTokens) GetTokenByHash(ctx context.Context, tokenHash string) (domain.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return domain.PersonalAccessToken{}, domain.ErrAccessTokenNotFound
+}
+
+func (r *memoryAccessTokens) ListTokens(ctx context.Context, userID int) ([]domain.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := []domain.PersonalAccessToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryAccessTokens) RevokeToken(ctx context.Context, userID, tokenID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, token := range r.tokens {
+		if token.ID == tokenID && token.UserID == userID && token.RevokedAt == nil {
+			now := time.Now()
+			r.tokens[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return domain.ErrAccessTokenNotFound
+}
+
+func (r *memoryAccessTokens) TouchToken(ctx context.Context, tokenID int, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, token := range r.tokens {
+		if token.ID == tokenID {
+			r.tokens[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package http_handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/clientip"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+func NewAuditHandler(service *service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// clientIP — адреса клієнта, визначена middleware ClientAddress з урахуванням довірених проксі.
+func clientIP(r *http.Request) string {
+	return clientip.FromRequest(r).IP
+}
+
+// clientCountry — країна клієнта від довіреного проксі або порожній рядок.
+func clientCountry(r *http.Request) string {
+	return clientip.FromRequest(r).Country
+}
+
+// recordAudit записує подію з IP та user agent запиту. Нульовий actorID або targetID означає "невідомо".
+func recordAudit(audit *service.AuditService, r *http.Request, eventType domain.AuditEventType, actorID, targetID int, details map[string]any) {
+	event := domain.AuditEvent{
+		Type:      eventType,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		Details:   details,
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	// Дії під час імперсонації завжди позначаються адміністратором, який їх виконав.
+	if impersonator, ok := auth.ImpersonatorFromContext(r.Context()); ok {
+		if event.Details == nil {
+			event.Details = map[string]any{}
+		}
+		event.Details["impersonator_id"] = impersonator.Subject
+	}
+	if targetID != 0 {
+		event.TargetID = &targetID
+	}
+
+	audit.Record(context.WithoutCancel(r.Context()), event)
+}
+
+func paging(r *http.Request) (limit, offset int) {
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+	return limit, offset
+}
+
+func (h *AuditHandler) UserActivityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	limit, offset := paging(r)
+
+	events, err := h.service.UserActivity(r.Context(), userID, limit, offset)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні активності користувача", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, events)
+}
+
+func (h *AuditHandler) AdminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var filter domain.AuditFilter
+	filter.Limit, filter.Offset = paging(r)
+
+	for param, dst := range map[string]**int{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if value := query.Get(param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				responseHTTP.Error(w, r, domain.NewValidationError(param, domain.RuleInvalid, nil), i18n.InvalidParameter)
+				return
+			}
+			*dst = &id
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				responseHTTP.Error(w, r, domain.NewValidationError(param, domain.RuleInvalid, nil), i18n.InvalidParameter)
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	for _, eventType := range query["type"] {
+		filter.Types = append(filter.Types, domain.AuditEventType(eventType))
+	}
+
+	events, err := h.service.Query(r.Context(), filter)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні журналу аудиту", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, events)
+}
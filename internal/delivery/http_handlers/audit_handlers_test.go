@@ -0,0 +1,161 @@
+package http_handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+)
+
+func TestUserActivity(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	rec := call(t, http.MethodGet, "/api/sso/user_profile/activity", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	events := decode[[]domain.AuditEvent](t, rec)
+	if len(events) == 0 {
+		t.Fatal("activity is empty after login")
+	}
+	for _, event := range events {
+		if event.TargetID == nil || *event.TargetID != u.ID {
+			t.Errorf("activity contains foreign event %+v", event)
+		}
+	}
+}
+
+func TestAdminAudit(t *testing.T) {
+	t.Parallel()
+
+	admin := newUser(t, "admin")
+	adminToken := login(t, admin)
+	u := newUser(t, "user")
+	login(t, u)
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/admin/audit", login(t, u), nil), http.StatusForbidden)
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/admin/audit?target_id=abc", adminToken, nil), http.StatusBadRequest)
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/admin/audit?from=yesterday", adminToken, nil), http.StatusBadRequest)
+	})
+
+	path := fmt.Sprintf("/api/sso/admin/audit?target_id=%d&type=%s", u.ID, domain.AuditLoginSuccess)
+	rec := call(t, http.MethodGet, path, adminToken, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	events := decode[[]domain.AuditEvent](t, rec)
+	if len(events) == 0 {
+		t.Fatal("no login events for the user")
+	}
+	for _, event := range events {
+		if event.Type != domain.AuditLoginSuccess || event.TargetID == nil || *event.TargetID != u.ID {
+			t.Errorf("filter returned unexpected event %+v", event)
+		}
+	}
+}
+
+// TestAuditClientIP перевіряє, що X-Forwarded-For від клієнта поза довіреними проксі не потрапляє в журнал.
+func TestAuditClientIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		remote string
+		want   string
+	}{
+		{"Direct", clientAddr, clientAddr},
+		{"Gateway", gatewayAddr, "192.0.2.44"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newUser(t, "user")
+
+			req := newRequest(t, http.MethodPost, "/api/sso/login", domain.LoginRequest{Email: u.Email, Password: u.Password})
+			req.Header.Set("X-Forwarded-For", "192.0.2.44")
+			req.RemoteAddr = tt.remote + ":443"
+			expectStatus(t, serve(req), http.StatusOK)
+
+			rec := call(t, http.MethodGet, "/api/sso/user_profile/activity", login(t, u), nil)
+			expectStatus(t, rec, http.StatusOK)
+
+			events := decode[[]domain.AuditEvent](t, rec)
+			first := events[len(events)-1]
+			if first.Type != domain.AuditLoginSuccess || first.IP != tt.want {
+				t.Errorf("first event = %s from %q, want %s from %q", first.Type, first.IP, domain.AuditLoginSuccess, tt.want)
+			}
+		})
+	}
+}
+
+type memoryAudit struct {
+	mu     sync.Mutex
+	nextID int64
+	events []domain.AuditEvent
+}
+
+func (r *memoryAudit) Append(ctx context.Context, event domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	event.ID = r.nextID
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryAudit) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	is := func(id *int, want *int) bool { return want == nil || (id != nil && *id == *want) }
+
+	events := []domain.AuditEvent{}
+	for i := len(r.events) - 1; i >= 0; i-- {
+		event := r.events[i]
+		if filter.UserID != nil && !is(event.ActorID, filter.UserID) && !is(event.TargetID, filter.UserID) {
+			continue
+		}
+		if !is(event.ActorID, filter.ActorID) || !is(event.TargetID, filter.TargetID) {
+			continue
+		}
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, event.Type) {
+			continue
+		}
+		if (filter.From != nil && event.CreatedAt.Before(*filter.From)) || (filter.To != nil && event.CreatedAt.After(*filter.To)) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if filter.Offset >= len(events) {
+		return []domain.AuditEvent{}, nil
+	}
+	events = events[filter.Offset:]
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// eventsOf повертає події одного типу, де користувач є ціллю.
+func (r *memoryAudit) eventsOf(eventType domain.AuditEventType, targetID int) []domain.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []domain.AuditEvent
+	for _, event := range r.events {
+		if event.Type == eventType && event.TargetID != nil && *event.TargetID == targetID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,119 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type ConsentHandler struct {
+	consent *service.ConsentService
+	audit   *service.AuditService
+}
+
+func NewConsentHandler(consent *service.ConsentService, audit *service.AuditService) *ConsentHandler {
+	return &ConsentHandler{
+		consent: consent,
+		audit:   audit,
+	}
+}
+
+func writeConsentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidClient):
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.UnknownClient)
+	case errors.Is(err, domain.ErrInvalidScope):
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidScope)
+	default:
+		responseHTTP.Error(w, r, err, "")
+	}
+}
+
+// ConsentPromptHandler повертає дані для екрана згоди: застосунок, запитані та вже надані дозволи.
+func (h *ConsentHandler) ConsentPromptHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	prompt, err := h.consent.Prompt(r.Context(), userID, query.Get("client_id"), domain.ParseScope(query.Get("scope")))
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при підготовці екрана згоди", "err", err.Error())
+		writeConsentError(w, r, err)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, prompt)
+}
+
+func (h *ConsentHandler) ConsentDecisionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.ConsentDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	details := map[string]any{"client_id": req.ClientID, "scopes": req.Scopes}
+
+	if !req.Approve {
+		recordAudit(h.audit, r, domain.AuditConsentDenied, userID, userID, details)
+		responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.AccessNotGranted)
+		return
+	}
+
+	if err := h.consent.Grant(r.Context(), userID, req.ClientID, req.Scopes); err != nil {
+		slog.DebugContext(r.Context(), "Помилка при збереженні згоди", "err", err.Error())
+		writeConsentError(w, r, err)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditConsentGranted, userID, userID, details)
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.AccessGranted)
+}
+
+func (h *ConsentHandler) ListConsentsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	grants, err := h.consent.List(r.Context(), userID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні згод", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, grants)
+}
+
+func (h *ConsentHandler) RevokeConsentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	clientID := mux.Vars(r)["client_id"]
+
+	if err := h.consent.Revoke(r.Context(), userID, clientID); err != nil {
+		slog.DebugContext(r.Context(), "Помилка при відкликанні згоди", "client_id", clientID, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.ConsentNotFound)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditConsentRevoked, userID, userID, map[string]any{"client_id": clientID})
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.ConsentRevoked)
+}
@@ -0,0 +1,124 @@
+package http_handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+)
+
+func TestConsent(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+	prompt := "/api/sso/consent?client_id=tv-app&scope=profile+email"
+
+	t.Run("UnknownClient", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/consent?client_id=nope&scope=profile", token, nil), http.StatusBadRequest)
+	})
+
+	rec := call(t, http.MethodGet, prompt, token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if p := decode[domain.ConsentPromptResponse](t, rec); !p.Required || len(p.Requested) != 2 {
+		t.Fatalf("prompt = %+v, want consent required for two scopes", p)
+	}
+
+	t.Run("ScopeOutsideClient", func(t *testing.T) {
+		decision := domain.ConsentDecisionRequest{ClientID: "tv-app", Scopes: []string{domain.ScopeInventoryWrite}, Approve: true}
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/consent", token, decision), http.StatusBadRequest)
+	})
+
+	decision := domain.ConsentDecisionRequest{ClientID: "tv-app", Scopes: []string{domain.ScopeProfile, domain.ScopeEmail}, Approve: true}
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/consent", token, decision), http.StatusOK)
+
+	rec = call(t, http.MethodGet, prompt, token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if p := decode[domain.ConsentPromptResponse](t, rec); p.Required {
+		t.Errorf("prompt after grant = %+v, want no consent required", p)
+	}
+
+	rec = call(t, http.MethodGet, "/api/sso/user_profile/consents", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if grants := decode[[]domain.ConsentGrant](t, rec); len(grants) != 1 || grants[0].ClientID != "tv-app" {
+		t.Fatalf("grants = %+v, want the tv-app grant", grants)
+	}
+
+	expectStatus(t, call(t, http.MethodDelete, "/api/sso/user_profile/consents/tv-app", token, nil), http.StatusOK)
+	expectStatus(t, call(t, http.MethodDelete, "/api/sso/user_profile/consents/tv-app", token, nil), http.StatusNotFound)
+	if len(env.audit.eventsOf(domain.AuditConsentRevoked, u.ID)) != 1 {
+		t.Error("consent revoked audit event not recorded")
+	}
+}
+
+type memoryConsents struct {
+	mu     sync.Mutex
+	grants map[string]domain.ConsentGrant
+	// failing — користувачі, для яких збереження згоди завершується помилкою бази.
+	failing map[int]bool
+}
+
+func (r *memoryConsents) failFor(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failing[userID] = true
+}
+
+func consentKey(userID int, clientID string) string {
+	return fmt.Sprintf("%d/%s", userID, clientID)
+}
+
+func (r *memoryConsents) GetConsent(ctx context.Context, userID int, clientID string) (domain.ConsentGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	grant, ok := r.grants[consentKey(userID, clientID)]
+	if !ok {
+		return grant, domain.ErrConsentNotFound
+	}
+	return grant, nil
+}
+
+func (r *memoryConsents) SaveConsent(ctx context.Context, grant domain.ConsentGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failing[grant.UserID] {
+		return errors.New("consent storage unavailable")
+	}
+	now := time.Now()
+	if existing, ok := r.grants[consentKey(grant.UserID, grant.ClientID)]; ok {
+		grant.CreatedAt = existing.CreatedAt
+	} else {
+		grant.CreatedAt = now
+	}
+	grant.UpdatedAt = now
+	r.grants[consentKey(grant.UserID, grant.ClientID)] = grant
+	return nil
+}
+
+func (r *memoryConsents) ListConsents(ctx context.Context, userID int) ([]domain.ConsentGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	grants := []domain.ConsentGrant{}
+	for _, grant := range r.grants {
+		if grant.UserID == userID {
+			grants = append(grants, grant)
+		}
+	}
+	return grants, nil
+}
+
+func (r *memoryConsents) RevokeConsent(ctx context.Context, userID int, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := consentKey(userID, clientID)
+	if _, ok := r.grants[key]; !ok {
+		return domain.ErrConsentNotFound
+	}
+	delete(r.grants, key)
+	return nil
+}
@@ -0,0 +1,114 @@
+package http_handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+	"time"
+)
+
+// SessionRefreshHandler оновлює браузерну сесію за токеном з cookie і замінює сам токен оновлення.
+// AuthMiddleware тут немає: токен доступу на цей момент зазвичай уже прострочений.
+func (h *UsersHandler) SessionRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.cookies.Enabled() {
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.CookieSessionsDisabled)
+		return
+	}
+
+	if err := auth.VerifyCSRF(r, r.Method); err != nil {
+		slog.DebugContext(r.Context(), "Оновлення сесії без CSRF-токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.InvalidCSRFToken)
+		return
+	}
+
+	cookie, err := r.Cookie(auth.RefreshTokenCookie)
+	if err != nil {
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	refresh, err := auth.ParseActionToken(cookie.Value, auth.PurposeRefresh)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Недійсний токен оновлення", "err", err.Error())
+		h.cookies.Clear(w)
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	if err := h.sessions.ConsumeRefresh(r.Context(), cookie.Value, refresh); err != nil {
+		slog.DebugContext(r.Context(), "Токен оновлення відхилено", "session_id", refresh.SessionID, "err", err.Error())
+		if errors.Is(err, domain.ErrRefreshReused) {
+			recordAudit(h.audit, r, domain.AuditTokenRevoked, refresh.UserID, refresh.UserID,
+				map[string]any{"session_id": refresh.SessionID, "reason": "refresh_reuse"})
+		}
+		h.cookies.Clear(w)
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	if err := h.sessions.Refresh(r.Context(), refresh.SessionID, refresh.UserID, clientIP(r), r.UserAgent()); err != nil {
+		slog.DebugContext(r.Context(), "Помилка при оновленні сесії", "err", err.Error())
+		h.cookies.Clear(w)
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	user, err := h.service.GetByID(r.Context(), refresh.UserID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні користувача", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	if err := h.service.EnsureActive(r.Context(), &user); err != nil {
+		slog.DebugContext(r.Context(), "Оновлення сесії заборонено статусом користувача", "user_id", user.UserID, "err", err.Error())
+		h.cookies.Clear(w)
+		writeStatusError(w, r, err)
+		return
+	}
+
+	claims := auth.JWTToken{Username: user.Login, UserID: user.UserID, SessionID: refresh.SessionID, Roles: user.Roles()}
+	claims.SetAuthContext(time.Unix(refresh.AuthTime, 0), refresh.AMR)
+
+	token, err := auth.IssueToken(claims, h.tokenTTL)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	csrfToken, err := h.cookies.Start(w, token)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створенні cookie-сесії", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.CookieSessionResponse{CSRFToken: csrfToken})
+}
+
+// LogoutHandler завершує поточну сесію і видаляє cookie.
+func (h *UsersHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні principal з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	if principal.SessionID != "" {
+		if err := h.sessions.Revoke(r.Context(), principal.UserID, principal.SessionID); err != nil {
+			slog.DebugContext(r.Context(), "Помилка при відкликанні сесії", "session_id", principal.SessionID, "err", err.Error())
+		} else {
+			recordAudit(h.audit, r, domain.AuditTokenRevoked, principal.UserID, principal.UserID,
+				map[string]any{"session_id": principal.SessionID, "reason": "logout"})
+		}
+	}
+
+	h.cookies.Clear(w)
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.LoggedOut)
+}
@@ -0,0 +1,159 @@
+package http_handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+// cookieLogin входить у режимі cookie і повертає встановлені cookie та CSRF-токен.
+func cookieLogin(t *testing.T, u testUser) ([]*http.Cookie, string) {
+	t.Helper()
+
+	rec := call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{
+		Email:       u.Email,
+		Password:    u.Password,
+		SessionMode: domain.SessionModeCookie,
+	})
+	expectStatus(t, rec, http.StatusOK)
+	return rec.Result().Cookies(), decode[domain.CookieSessionResponse](t, rec).CSRFToken
+}
+
+func withCookies(req *http.Request, cookies []*http.Cookie) *http.Request {
+	for _, c := range cookies {
+		if c.MaxAge >= 0 {
+			req.AddCookie(c)
+		}
+	}
+	return req
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestCookieLogin(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+
+	rec := call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{
+		Email:       u.Email,
+		Password:    u.Password,
+		SessionMode: domain.SessionModeCookie,
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	if decode[domain.CookieSessionResponse](t, rec).CSRFToken == "" {
+		t.Error("cookie login returned no CSRF token")
+	}
+	for _, name := range []string{auth.AccessTokenCookie, auth.RefreshTokenCookie} {
+		c := findCookie(rec, name)
+		if c == nil || !c.HttpOnly {
+			t.Errorf("cookie %s = %+v, want HttpOnly cookie", name, c)
+		}
+	}
+	if c := findCookie(rec, auth.CSRFCookie); c == nil || c.HttpOnly {
+		t.Errorf("CSRF cookie = %+v, want cookie readable by scripts", c)
+	}
+}
+
+func TestCookieAuthentication(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	cookies, csrf := cookieLogin(t, u)
+
+	rec := serve(withCookies(newRequest(t, http.MethodGet, "/api/sso/user_profile", nil), cookies))
+	expectStatus(t, rec, http.StatusOK)
+
+	t.Run("UnsafeMethodRequiresCSRF", func(t *testing.T) {
+		req := withCookies(newRequest(t, http.MethodPost, "/api/sso/session/logout", nil), cookies)
+		expectStatus(t, serve(req), http.StatusForbidden)
+
+		req = withCookies(newRequest(t, http.MethodPost, "/api/sso/session/logout", nil), cookies)
+		req.Header.Set(auth.CSRFHeader, "forged")
+		expectStatus(t, serve(req), http.StatusForbidden)
+	})
+
+	req := withCookies(newRequest(t, http.MethodPost, "/api/sso/session/logout", nil), cookies)
+	req.Header.Set(auth.CSRFHeader, csrf)
+	rec = serve(req)
+	expectStatus(t, rec, http.StatusOK)
+	if c := findCookie(rec, auth.AccessTokenCookie); c == nil || c.MaxAge >= 0 {
+		t.Errorf("logout access cookie = %+v, want it cleared", c)
+	}
+
+	// Після виходу сесія відкликана, тож старі cookie більше не діють.
+	rec = serve(withCookies(newRequest(t, http.MethodGet, "/api/sso/user_profile", nil), cookies))
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestSessionRefresh(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	cookies, csrf := cookieLogin(t, u)
+
+	t.Run("RequiresCSRF", func(t *testing.T) {
+		req := withCookies(newRequest(t, http.MethodPost, "/api/sso/session/refresh", nil), cookies)
+		expectStatus(t, serve(req), http.StatusForbidden)
+	})
+
+	t.Run("RequiresRefreshCookie", func(t *testing.T) {
+		req := newRequest(t, http.MethodPost, "/api/sso/session/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: auth.CSRFCookie, Value: csrf})
+		req.Header.Set(auth.CSRFHeader, csrf)
+		expectStatus(t, serve(req), http.StatusUnauthorized)
+	})
+
+	req := withCookies(newRequest(t, http.MethodPost, "/api/sso/session/refresh", nil), cookies)
+	req.Header.Set(auth.CSRFHeader, csrf)
+	rec := serve(req)
+	expectStatus(t, rec, http.StatusOK)
+
+	if next := decode[domain.CookieSessionResponse](t, rec).CSRFToken; next == "" || next == csrf {
+		t.Errorf("refreshed CSRF token = %q, want a new one", next)
+	}
+	access := findCookie(rec, auth.AccessTokenCookie)
+	if access == nil {
+		t.Fatal("refresh did not set a new access cookie")
+	}
+	claims, err := auth.ParseToken(access.Value)
+	if err != nil || claims.UserID != u.ID {
+		t.Errorf("refreshed access token claims = %+v, err = %v", claims, err)
+	}
+
+	refresh := func(cookies []*http.Cookie, csrf string) *httptest.ResponseRecorder {
+		req := withCookies(newRequest(t, http.MethodPost, "/api/sso/session/refresh", nil), cookies)
+		req.Header.Set(auth.CSRFHeader, csrf)
+		return serve(req)
+	}
+
+	// Токен оновлення замінюється при кожному використанні: новий діє, а повтор старого
+	// означає викрадення і відкликає сесію разом із новим токеном.
+	rotated := rec.Result().Cookies()
+	for _, c := range cookies {
+		if next := findCookie(rec, auth.RefreshTokenCookie); c.Name == auth.RefreshTokenCookie && (next == nil || next.Value == c.Value) {
+			t.Fatalf("refresh cookie = %+v, want a new token", next)
+		}
+	}
+	rec = refresh(rotated, decode[domain.CookieSessionResponse](t, rec).CSRFToken)
+	expectStatus(t, rec, http.StatusOK)
+	latest, latestCSRF := rec.Result().Cookies(), decode[domain.CookieSessionResponse](t, rec).CSRFToken
+
+	expectStatus(t, refresh(cookies, csrf), http.StatusUnauthorized)
+	if len(env.audit.eventsOf(domain.AuditTokenRevoked, u.ID)) != 1 {
+		t.Error("refresh token reuse audit event not recorded")
+	}
+	expectStatus(t, refresh(latest, latestCSRF), http.StatusUnauthorized)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", access.Value, nil), http.StatusUnauthorized)
+}
@@ -0,0 +1,114 @@
+package http_handlers
+
+import (
+	"net/http"
+	"sso-service/pkg/auth"
+	"time"
+)
+
+const (
+	refreshCookiePath    = "/api/sso/session"
+	oauthStateCookiePath = "/api/sso/oauth"
+	oauthStateCookieTTL  = 10 * time.Minute
+)
+
+type CookieOptions struct {
+	Enabled    bool
+	Domain     string
+	Secure     bool
+	SameSite   http.SameSite
+	RefreshTTL time.Duration
+}
+
+// CookieSessions видає браузерам токени в HttpOnly cookie разом із CSRF-токеном для double-submit.
+type CookieSessions struct {
+	opts     CookieOptions
+	tokenTTL time.Duration
+}
+
+func NewCookieSessions(opts CookieOptions, tokenTTL time.Duration) *CookieSessions {
+	return &CookieSessions{opts: opts, tokenTTL: tokenTTL}
+}
+
+func (c *CookieSessions) Enabled() bool {
+	return c.opts.Enabled
+}
+
+func (c *CookieSessions) cookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.opts.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   c.opts.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.opts.SameSite,
+	}
+}
+
+// Start кладе токен доступу в cookie, випускає до нього новий токен оновлення і новий CSRF-токен.
+// Токен оновлення одноразовий (SessionsService.ConsumeRefresh), тож Nonce робить кожен з них унікальним.
+func (c *CookieSessions) Start(w http.ResponseWriter, accessToken string) (string, error) {
+	claims, err := auth.ParseToken(accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := auth.NewCSRFToken()
+	if err != nil {
+		return "", err
+	}
+
+	refreshToken, err := auth.IssueActionToken(auth.ActionToken{
+		Purpose:   auth.PurposeRefresh,
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Nonce:     nonce,
+		AuthTime:  claims.AuthTime,
+		AMR:       claims.AMR,
+	}, c.opts.RefreshTTL)
+	if err != nil {
+		return "", err
+	}
+
+	csrfToken, err := auth.NewCSRFToken()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, c.cookie(auth.AccessTokenCookie, accessToken, "/", c.tokenTTL, true))
+	http.SetCookie(w, c.cookie(auth.RefreshTokenCookie, refreshToken, refreshCookiePath, c.opts.RefreshTTL, true))
+	http.SetCookie(w, c.cookie(auth.CSRFCookie, csrfToken, "/", c.opts.RefreshTTL, false))
+
+	return csrfToken, nil
+}
+
+// Clear видаляє всі cookie сесії.
+func (c *CookieSessions) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(auth.AccessTokenCookie, "", "/", -time.Second, true))
+	http.SetCookie(w, c.cookie(auth.RefreshTokenCookie, "", refreshCookiePath, -time.Second, true))
+	http.SetCookie(w, c.cookie(auth.CSRFCookie, "", "/", -time.Second, false))
+}
+
+// SetOAuthState запам'ятовує в браузері nonce state входу через провайдера. Cookie діє незалежно від
+// cookies.enabled: без неї колбек провайдера не відрізнить свій браузер від чужого.
+// Apple повертає відповідь POST-запитом з іншого сайту, тож через https cookie має SameSite=None.
+func (c *CookieSessions) SetOAuthState(w http.ResponseWriter, nonce string) {
+	cookie := c.cookie(auth.OAuthStateCookie, nonce, oauthStateCookiePath, oauthStateCookieTTL, true)
+	cookie.SameSite = http.SameSiteLaxMode
+	if c.opts.Secure {
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(w, cookie)
+}
+
+// OAuthState повертає nonce, покладений SetOAuthState, і видаляє cookie — state одноразовий.
+func (c *CookieSessions) OAuthState(w http.ResponseWriter, r *http.Request) string {
+	cookie, err := r.Cookie(auth.OAuthStateCookie)
+	if err != nil {
+		return ""
+	}
+	http.SetCookie(w, c.cookie(auth.OAuthStateCookie, "", oauthStateCookiePath, -time.Second, true))
+	return cookie.Value
+}
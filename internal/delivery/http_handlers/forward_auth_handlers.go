@@ -0,0 +1,104 @@
+package http_handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"sso-service/internal/domain"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+	"strconv"
+	"strings"
+)
+
+type ForwardAuthHandler struct {
+	forwardAuth *service.ForwardAuthService
+}
+
+func NewForwardAuthHandler(forwardAuth *service.ForwardAuthService) *ForwardAuthHandler {
+	return &ForwardAuthHandler{forwardAuth: forwardAuth}
+}
+
+// VerifyHandler — forward-auth для nginx auth_request / Traefik ForwardAuth.
+// Параметри: audience — ідентифікатор сервісу за маршрутом (токен, звужений до іншого сервісу, відхиляється),
+// scope (усі обов'язкові) та role (достатньо однієї), можна повторювати.
+// Делегований токен (персональний, застосунку) не проходить жодної перевірки role і не отримує X-User-Roles.
+// Відповідь без тіла: 200 із заголовками X-User-* та X-Token-Audience, 401 або 403.
+func (h *ForwardAuthHandler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, fromCookie, ok := auth.TokenFromRequest(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Шлюз передає метод оригінального запиту; без нього вважаємо запит таким, що змінює стан.
+	if fromCookie {
+		method := r.Header.Get("X-Forwarded-Method")
+		if method == "" {
+			method = r.Header.Get("X-Original-Method")
+		}
+		if method == "" {
+			method = http.MethodPost
+		}
+		if err := auth.VerifyCSRF(r, method); err != nil {
+			slog.DebugContext(r.Context(), "Forward-auth: запит з cookie без CSRF-токена", "err", err.Error())
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	query := r.URL.Query()
+
+	token, err := h.forwardAuth.Verify(r.Context(), tokenString, query.Get("audience"))
+	if err != nil {
+		slog.DebugContext(r.Context(), "Forward-auth: токен відхилено", "err", err.Error())
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	principal := auth.NewPrincipal(token)
+
+	for _, scope := range domain.ParseScope(strings.Join(query["scope"], " ")) {
+		if !principal.HasScope(scope) {
+			slog.DebugContext(r.Context(), "Forward-auth: бракує дозволу", "user_id", principal.UserID, "scope", scope)
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	// Персональний токен чи токен застосунку не передає ролей власника — як і RequireAdmin в API.
+	roles := principal.Roles
+	if token.Delegated() {
+		roles = nil
+	}
+
+	if required := query["role"]; len(required) > 0 && !slices.ContainsFunc(required, func(role string) bool {
+		return slices.Contains(roles, role)
+	}) {
+		slog.DebugContext(r.Context(), "Forward-auth: бракує ролі", "user_id", principal.UserID, "roles", required)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("X-User-Id", strconv.Itoa(principal.UserID))
+	w.Header().Set("X-User-Login", principal.Username)
+	if len(roles) > 0 {
+		w.Header().Set("X-User-Roles", strings.Join(roles, ","))
+	}
+	if len(principal.Scopes) > 0 {
+		w.Header().Set("X-User-Scopes", strings.Join(principal.Scopes, " "))
+	}
+	if principal.OrgID != 0 {
+		w.Header().Set("X-User-Org-Id", strconv.Itoa(principal.OrgID))
+	}
+	if token.Audience != "" {
+		w.Header().Set("X-Token-Audience", token.Audience)
+	}
+	if impersonator, ok := token.Impersonator(); ok {
+		w.Header().Set("X-Impersonator-Id", impersonator.Subject)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,70 @@
+package http_handlers_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+func TestForwardAuth(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	t.Run("NoToken", func(t *testing.T) {
+		rec := call(t, http.MethodGet, "/api/sso/auth/verify", "", nil)
+		expectStatus(t, rec, http.StatusUnauthorized)
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Error("401 without WWW-Authenticate")
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/auth/verify", "garbage", nil), http.StatusUnauthorized)
+	})
+
+	rec := call(t, http.MethodGet, "/api/sso/auth/verify", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("X-User-Id"); got != strconv.Itoa(u.ID) {
+		t.Errorf("X-User-Id = %q, want %d", got, u.ID)
+	}
+	if got := rec.Header().Get("X-User-Login"); got != u.Login {
+		t.Errorf("X-User-Login = %q, want %q", got, u.Login)
+	}
+
+	t.Run("RoleRequired", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/auth/verify?role=admin", token, nil), http.StatusForbidden)
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/auth/verify?role=admin&role=user", token, nil), http.StatusOK)
+	})
+
+	t.Run("DelegatedTokenHasNoRoles", func(t *testing.T) {
+		pat := createToken(t, login(t, newUser(t, "admin")), domain.ScopeInventoryRead)
+
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/auth/verify?role=admin", pat, nil), http.StatusForbidden)
+
+		rec := call(t, http.MethodGet, "/api/sso/auth/verify", pat, nil)
+		expectStatus(t, rec, http.StatusOK)
+		if got := rec.Header().Get("X-User-Roles"); got != "" {
+			t.Errorf("X-User-Roles = %q for a personal token, want none", got)
+		}
+	})
+
+	t.Run("CookieRequiresCSRFForUnsafeMethods", func(t *testing.T) {
+		cookies, csrf := cookieLogin(t, u)
+
+		req := withCookies(newRequest(t, http.MethodGet, "/api/sso/auth/verify", nil), cookies)
+		req.Header.Set("X-Forwarded-Method", http.MethodGet)
+		expectStatus(t, serve(req), http.StatusOK)
+
+		req = withCookies(newRequest(t, http.MethodGet, "/api/sso/auth/verify", nil), cookies)
+		req.Header.Set("X-Forwarded-Method", http.MethodPost)
+		expectStatus(t, serve(req), http.StatusForbidden)
+
+		req.Header.Set(auth.CSRFHeader, csrf)
+		expectStatus(t, serve(req), http.StatusOK)
+	})
+}
@@ -0,0 +1,92 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ImpersonateHandler видає адміністратору короткоживучий токен від імені користувача.
+// Токен прив'язаний до окремої сесії, тож користувач бачить її у списку і може відкликати.
+func (h *UsersHandler) ImpersonateHandler(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidUserID)
+		return
+	}
+
+	var req domain.ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	if req.Reason == "" {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.ReasonRequired)
+		return
+	}
+
+	admin, ok := auth.PrincipalFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні principal з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+	adminID, adminLogin := admin.UserID, admin.Username
+
+	target, err := h.service.ImpersonationTarget(r.Context(), adminID, targetID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Імперсонацію заборонено", "admin_id", adminID, "user_id", targetID, "err", err.Error())
+		var statusErr *domain.UserStatusError
+		switch {
+		case errors.As(err, &statusErr):
+			writeStatusError(w, r, err)
+		case errors.Is(err, domain.ErrForbidden):
+			responseHTTP.Error(w, r, err, i18n.CannotImpersonate)
+		default:
+			responseHTTP.Error(w, r, err, i18n.UserNotFound)
+		}
+		return
+	}
+
+	session, err := h.sessions.Start(r.Context(), target.UserID, "Підтримка CarVia ("+adminLogin+")", clientIP(r), r.UserAgent())
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створенні сесії", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	expiresAt := time.Now().Add(h.impersonationTTL)
+	claims := auth.JWTToken{
+		Username:  target.Login,
+		UserID:    target.UserID,
+		SessionID: session.ID,
+		Roles:     target.Roles(),
+		Act:       &auth.Actor{Subject: strconv.Itoa(adminID), Username: adminLogin},
+	}
+	claims.ExpiresAt = expiresAt.Unix()
+
+	token, err := auth.IssueToken(claims, h.impersonationTTL)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditImpersonationStarted, adminID, target.UserID, map[string]any{
+		"reason":     req.Reason,
+		"session_id": session.ID,
+		"expires_at": expiresAt.Format(time.RFC3339),
+	})
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.ImpersonationResponse{Token: token, ExpiresAt: expiresAt})
+}
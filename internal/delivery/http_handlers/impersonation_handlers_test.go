@@ -0,0 +1,81 @@
+package http_handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+func TestImpersonate(t *testing.T) {
+	t.Parallel()
+
+	admin := newUser(t, "admin")
+	adminToken := login(t, admin)
+	u := newUser(t, "user")
+	path := fmt.Sprintf("/api/sso/admin/users/%d/impersonate", u.ID)
+
+	t.Run("ReasonRequired", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodPost, path, adminToken, domain.ImpersonateRequest{}), http.StatusBadRequest)
+	})
+
+	t.Run("RequiresRecentAuthentication", func(t *testing.T) {
+		rec := call(t, http.MethodPost, path, staleToken(t, admin), domain.ImpersonateRequest{Reason: "support ticket"})
+		expectStatus(t, rec, http.StatusUnauthorized)
+	})
+
+	t.Run("NotForRegularUsers", func(t *testing.T) {
+		rec := call(t, http.MethodPost, path, login(t, newUser(t, "user")), domain.ImpersonateRequest{Reason: "support ticket"})
+		expectStatus(t, rec, http.StatusForbidden)
+	})
+
+	t.Run("Targets", func(t *testing.T) {
+		impersonate := func(targetID int) int {
+			path := fmt.Sprintf("/api/sso/admin/users/%d/impersonate", targetID)
+			return call(t, http.MethodPost, path, adminToken, domain.ImpersonateRequest{Reason: "support ticket"}).Code
+		}
+		if got := impersonate(admin.ID); got != http.StatusForbidden {
+			t.Errorf("impersonating yourself: status = %d, want 403", got)
+		}
+		if got := impersonate(newUser(t, "admin").ID); got != http.StatusForbidden {
+			t.Errorf("impersonating another admin: status = %d, want 403", got)
+		}
+		if got := impersonate(1 << 30); got != http.StatusNotFound {
+			t.Errorf("impersonating a missing user: status = %d, want 404", got)
+		}
+	})
+
+	rec := call(t, http.MethodPost, path, adminToken, domain.ImpersonateRequest{Reason: "support ticket"})
+	expectStatus(t, rec, http.StatusOK)
+	resp := decode[domain.ImpersonationResponse](t, rec)
+
+	claims, err := auth.ParseToken(resp.Token)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if claims.UserID != u.ID || claims.Act == nil || claims.Act.Subject != strconv.Itoa(admin.ID) {
+		t.Errorf("impersonation claims = %+v, want user %d acting as admin %d", claims, u.ID, admin.ID)
+	}
+	if len(env.audit.eventsOf(domain.AuditImpersonationStarted, u.ID)) != 1 {
+		t.Error("impersonation audit event not recorded")
+	}
+
+	// Токен імперсонації працює для звичайних маршрутів, але не для чутливих та адмінських.
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", resp.Token, nil), http.StatusOK)
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/refresh", resp.Token, nil), http.StatusForbidden)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/admin/audit", resp.Token, nil), http.StatusForbidden)
+
+	// Профіль редагується без пароля користувача, а змінити сам пароль не можна.
+	t.Run("UpdateProfile", func(t *testing.T) {
+		fields := profileFields(u)
+		fields["Address"] = "Львів"
+		expectStatus(t, updateProfile(t, resp.Token, fields), http.StatusOK)
+
+		fields["Password"] = "admin-chosen-password"
+		expectStatus(t, updateProfile(t, resp.Token, fields), http.StatusForbidden)
+		login(t, u)
+	})
+}
@@ -0,0 +1,21 @@
+package http_handlers
+
+import (
+	"net/http"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+)
+
+type KeysHandler struct {
+	keys *auth.KeySet
+}
+
+func NewKeysHandler(keys *auth.KeySet) *KeysHandler {
+	return &KeysHandler{keys: keys}
+}
+
+// JWKSHandler публікує ключі, якими сервіси перевіряють токени SSO без спільного секрету.
+func (h *KeysHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	responseHTTP.JSONResp(w, http.StatusOK, h.keys.JWKS())
+}
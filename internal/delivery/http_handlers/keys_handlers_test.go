@@ -0,0 +1,135 @@
+package http_handlers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"sso-service/pkg/auth"
+)
+
+// tokenKeyID читає kid із заголовка JWT.
+func tokenKeyID(t *testing.T, token string) string {
+	t.Helper()
+
+	header, _, _ := strings.Cut(token, ".")
+	data, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		t.Fatalf("decode token header: %v", err)
+	}
+	var fields struct {
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("parse token header: %v", err)
+	}
+	return fields.Kid
+}
+
+func TestJWKS(t *testing.T) {
+	t.Parallel()
+
+	rec := call(t, http.MethodGet, "/api/sso/.well-known/jwks.json", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	if !strings.Contains(rec.Header().Get("Cache-Control"), "max-age") {
+		t.Errorf("Cache-Control = %q, want cacheable response", rec.Header().Get("Cache-Control"))
+	}
+
+	jwks := decode[auth.JWKS](t, rec)
+	if len(jwks.Keys) == 0 {
+		t.Fatal("JWKS has no keys")
+	}
+
+	// Токени сервісу підписані одним з опублікованих ключів.
+	kid := tokenKeyID(t, login(t, newUser(t, "user")))
+	for _, key := range jwks.Keys {
+		if key.Kid == kid {
+			if key.Kty != "RSA" || key.Alg != "RS256" || key.N == "" || key.E == "" {
+				t.Errorf("signing key = %+v, want RS256 public key", key)
+			}
+			return
+		}
+	}
+	t.Errorf("signing key %q is not published", kid)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if _, err := auth.LoadKeySet(dir, time.Hour); !errors.Is(err, auth.ErrNoSigningKeys) {
+		t.Fatalf("LoadKeySet on empty dir = %v, want ErrNoSigningKeys", err)
+	}
+
+	active, err := auth.GenerateSigningKey(dir)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	// Ключ, замінений два дні тому, виведено з обігу; замінений годину тому ще публікується.
+	now := time.Now()
+	data, err := os.ReadFile(filepath.Join(dir, active.ID+".pem"))
+	if err != nil {
+		t.Fatalf("read key: %v", err)
+	}
+	expired := auth.NewKeyID(now.Add(-72 * time.Hour))
+	previous := auth.NewKeyID(now.Add(-48 * time.Hour))
+	for _, id := range []string{expired, previous} {
+		if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+			t.Fatalf("write key: %v", err)
+		}
+	}
+
+	keys, err := auth.LoadKeySet(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	var published []string
+	for _, key := range keys.JWKS().Keys {
+		published = append(published, key.Kid)
+	}
+	if want := []string{previous, active.ID}; !slices.Equal(published, want) {
+		t.Errorf("published keys = %v, want %v", published, want)
+	}
+
+	removed, err := auth.PruneSigningKeys(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PruneSigningKeys: %v", err)
+	}
+	if !slices.Equal(removed, []string{expired}) {
+		t.Errorf("removed keys = %v, want [%s]", removed, expired)
+	}
+	if _, err := os.Stat(filepath.Join(dir, expired+".pem")); !os.IsNotExist(err) {
+		t.Errorf("retired key file still exists: %v", err)
+	}
+}
+
+// Ключ, створений у ту саму секунду, має той самий kid і не сміє перезаписати попередній.
+func TestGenerateSigningKeyCollision(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	for i := range 10 {
+		path := filepath.Join(dir, auth.NewKeyID(now.Add(time.Duration(i)*time.Second))+".pem")
+		if err := os.WriteFile(path, []byte("existing"), 0o600); err != nil {
+			t.Fatalf("write key: %v", err)
+		}
+	}
+
+	if _, err := auth.GenerateSigningKey(dir); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("GenerateSigningKey over an existing kid = %v, want os.ErrExist", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, auth.NewKeyID(now)+".pem"))
+	if err != nil || string(data) != "existing" {
+		t.Errorf("existing key file = %q, %v; want it untouched", data, err)
+	}
+}
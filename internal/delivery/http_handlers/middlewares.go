@@ -0,0 +1,175 @@
+package http_handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/lib/clientip"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/requestid"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RequireAdmin пропускає лише користувачів з роллю admin. Роль перевіряється по БД,
+// а не по токену, щоб зняття ролі діяло одразу.
+func (h *UsersHandler) RequireAdmin(next func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := auth.UserIDFrom(r.Context())
+		if !ok {
+			slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+			responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+			return
+		}
+
+		// Персональний токен чи токен застосунку не дає прав адміністратора, навіть якщо їх має власник.
+		if token, ok := auth.TokenFromContext(r.Context()); ok && token.Delegated() {
+			slog.DebugContext(r.Context(), "Адмін-маршрут недоступний для делегованого токена", "user_id", userID)
+			responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.Forbidden)
+			return
+		}
+
+		// Токен імперсонації не дає прав адміністратора, навіть якщо їх має сам адмін.
+		if _, impersonating := auth.ImpersonatorFromContext(r.Context()); impersonating {
+			slog.DebugContext(r.Context(), "Адмін-маршрут недоступний під час імперсонації", "user_id", userID)
+			responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.Forbidden)
+			return
+		}
+
+		user, err := h.service.GetByID(r.Context(), userID)
+		if err != nil {
+			slog.DebugContext(r.Context(), "Помилка при отриманні користувача", "err", err.Error())
+			responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+			return
+		}
+
+		if user.Role != "admin" {
+			slog.DebugContext(r.Context(), "Доступ до адмін-маршруту заборонено", "user_id", userID)
+			responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.Forbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// RequestID приймає X-Request-ID від шлюзу або генерує новий, кладе його в контекст і повертає клієнту.
+// Некоректний заголовок замінюється, щоб чужі дані не потрапили в журнал.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = uuid.New().String()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+	})
+}
+
+// ClientAddress визначає адресу клієнта один раз на запит; заголовки проксі враховуються,
+// лише якщо запит прийшов від довіреного проксі.
+func ClientAddress(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := clientip.WithClient(r.Context(), resolver.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestLog — дані рядка журналу запитів, які стають відомі глибше в ланцюжку:
+// шаблон маршруту після маршрутизації і user_id після автентифікації.
+type requestLog struct {
+	route  string
+	userID int
+}
+
+type requestLogContextKey struct{}
+
+func requestLogFrom(ctx context.Context) (*requestLog, bool) {
+	entry, ok := ctx.Value(requestLogContextKey{}).(*requestLog)
+	return entry, ok
+}
+
+// statusRecorder запам'ятовує статус і розмір відповіді.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap дає http.ResponseController дістатися до справжнього ResponseWriter.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// AccessLog пише один рядок журналу на запит: метод, шаблон маршруту, статус, розмір відповіді,
+// тривалість, user_id та IP клієнта. Має стояти після RequestID, щоб рядок містив request_id.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &requestLog{}
+		rec := &statusRecorder{ResponseWriter: w}
+
+		r = r.WithContext(context.WithValue(r.Context(), requestLogContextKey{}, entry))
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", entry.route),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", clientIP(r)),
+		}
+		if entry.userID != 0 {
+			attrs = append(attrs, slog.Int("user_id", entry.userID))
+		}
+		slog.LogAttrs(r.Context(), slog.LevelInfo, "HTTP-запит", attrs...)
+	})
+}
+
+// RouteTemplate — middleware для mux.Router: журнал отримує шаблон маршруту, а не шлях,
+// тож /api/sso/sessions/{id} не розсипається на окремі рядки для кожного id.
+func RouteTemplate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := requestLogFrom(r.Context()); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				entry.route, _ = route.GetPathTemplate()
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LogAuthenticatedUser — auth.AuthenticatedHook, що додає user_id до рядка журналу запитів.
+func LogAuthenticatedUser(ctx context.Context, principal *auth.Principal) {
+	if entry, ok := requestLogFrom(ctx); ok {
+		entry.userID = principal.UserID
+	}
+}
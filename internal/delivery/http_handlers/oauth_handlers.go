@@ -0,0 +1,163 @@
+package http_handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type OAuthHandler struct {
+	federation *service.FederationService
+	users      *service.UsersService
+	sessions   *service.SessionsService
+	audit      *service.AuditService
+	cookies    *CookieSessions
+	tokenTTL   time.Duration
+}
+
+func NewOAuthHandler(federation *service.FederationService, users *service.UsersService, sessions *service.SessionsService,
+	audit *service.AuditService, cookies *CookieSessions, tokenTTL time.Duration) *OAuthHandler {
+	return &OAuthHandler{
+		federation: federation,
+		users:      users,
+		sessions:   sessions,
+		audit:      audit,
+		cookies:    cookies,
+		tokenTTL:   tokenTTL,
+	}
+}
+
+func (h *OAuthHandler) LoginRedirectHandler(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	authURL, nonce, err := h.federation.AuthURL(provider, 0)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при формуванні адреси провайдера", "provider", provider, "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.ProviderNotFound)
+		return
+	}
+
+	h.cookies.SetOAuthState(w, nonce)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// CallbackHandler приймає GET-редирект або POST (response_mode=form_post у Apple).
+func (h *OAuthHandler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	browserNonce := h.cookies.OAuthState(w, r)
+
+	if errCode := r.FormValue("error"); errCode != "" {
+		slog.DebugContext(r.Context(), "Провайдер повернув помилку", "provider", provider, "error", errCode)
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.LoginCancelled)
+		return
+	}
+
+	result, err := h.federation.Complete(r.Context(), provider, r.FormValue("code"), r.FormValue("state"), browserNonce)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка входу через провайдера", "provider", provider, "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, 0, map[string]any{"provider": provider, "reason": "federation"})
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.ProviderLoginFailed)
+		return
+	}
+
+	user := result.User
+
+	if result.Created {
+		recordAudit(h.audit, r, domain.AuditRegistration, user.UserID, user.UserID, map[string]any{"provider": provider})
+	}
+	if result.Linked {
+		recordAudit(h.audit, r, domain.AuditIdentityLinked, user.UserID, user.UserID, map[string]any{"provider": provider})
+	}
+
+	if result.LinkOnly {
+		responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.IdentityLinked)
+		return
+	}
+
+	if err := h.users.EnsureActive(r.Context(), &user); err != nil {
+		slog.DebugContext(r.Context(), "Вхід заборонено статусом користувача", "user_id", user.UserID, "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, user.UserID, map[string]any{"provider": provider, "reason": "status"})
+		writeStatusError(w, r, err)
+		return
+	}
+
+	token, sessionID, err := issueSessionToken(r, h.sessions, h.tokenTTL, user, provider, []string{auth.AMRFederated})
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditLoginSuccess, user.UserID, user.UserID, map[string]any{"provider": provider, "session_id": sessionID})
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.TokenResponse{Token: token})
+}
+
+func (h *OAuthHandler) ListIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	identities, err := h.federation.Identities(r.Context(), userID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні зовнішніх облікових записів", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, identities)
+}
+
+// LinkIdentityHandler повертає адресу провайдера, на яку фронтенд перенаправляє користувача для прив'язки.
+// Фронтенд має робити запит з credentials, щоб браузер зберіг cookie зі state.
+func (h *OAuthHandler) LinkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	provider := mux.Vars(r)["provider"]
+
+	authURL, nonce, err := h.federation.AuthURL(provider, userID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при формуванні адреси провайдера", "provider", provider, "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.ProviderNotFound)
+		return
+	}
+
+	h.cookies.SetOAuthState(w, nonce)
+	responseHTTP.JSONResp(w, http.StatusOK, domain.AuthURLResponse{URL: authURL})
+}
+
+func (h *OAuthHandler) UnlinkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	provider := mux.Vars(r)["provider"]
+
+	if err := h.federation.Unlink(r.Context(), userID, provider); err != nil {
+		slog.DebugContext(r.Context(), "Помилка при відв'язці провайдера", "provider", provider, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.IdentityNotFound)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditIdentityUnlinked, userID, userID, map[string]any{"provider": provider})
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.IdentityUnlinked)
+}
@@ -0,0 +1,264 @@
+package http_handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/federation"
+	"sso-service/pkg/auth"
+)
+
+// browserState — state з адреси провайдера разом із cookie, яку сервіс поклав у браузер, що почав вхід.
+type browserState struct {
+	state  string
+	cookie *http.Cookie
+}
+
+// startLogin починає вхід через тестового провайдера з нового браузера.
+func startLogin(t *testing.T) browserState {
+	t.Helper()
+
+	rec := call(t, http.MethodGet, "/api/sso/oauth/test/login", "", nil)
+	expectStatus(t, rec, http.StatusFound)
+	return browserState{state: providerState(t, rec.Header().Get("Location")), cookie: oauthStateCookie(t, rec)}
+}
+
+func oauthStateCookie(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == auth.OAuthStateCookie && cookie.Value != "" {
+			if !cookie.HttpOnly {
+				t.Error("oauth state cookie is readable from JavaScript")
+			}
+			return cookie
+		}
+	}
+	t.Fatal("no oauth state cookie set")
+	return nil
+}
+
+// providerState повертає state з адреси, на яку сервіс перенаправив користувача.
+func providerState(t *testing.T, authURL string) string {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse provider url %q: %v", authURL, err)
+	}
+	state := parsed.Query().Get("state")
+	if state == "" {
+		t.Fatalf("provider url %q has no state", authURL)
+	}
+	return state
+}
+
+// callback повертає браузер з провайдера; cookie nil — браузер, який вхід не починав.
+func callback(t *testing.T, code, state string, cookie *http.Cookie) *http.Request {
+	t.Helper()
+	query := url.Values{"code": {code}, "state": {state}}
+	req := newRequest(t, http.MethodGet, "/api/sso/oauth/test/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestOAuthLogin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnknownProvider", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/oauth/nope/login", "", nil), http.StatusNotFound)
+	})
+
+	browser := startLogin(t)
+
+	t.Run("InvalidState", func(t *testing.T) {
+		expectStatus(t, serve(callback(t, "sub-1:fed-1@carvia.test", "forged", browser.cookie)), http.StatusUnauthorized)
+	})
+
+	// Login CSRF: посилання на колбек з чужим state у браузері, що вхід не починав, не спрацьовує.
+	t.Run("AnotherBrowser", func(t *testing.T) {
+		expectStatus(t, serve(callback(t, "sub-1:fed-1@carvia.test", browser.state, nil)), http.StatusUnauthorized)
+		other := startLogin(t)
+		expectStatus(t, serve(callback(t, "sub-1:fed-1@carvia.test", browser.state, other.cookie)), http.StatusUnauthorized)
+	})
+
+	t.Run("ProviderError", func(t *testing.T) {
+		rec := call(t, http.MethodGet, "/api/sso/oauth/test/callback?error=access_denied", "", nil)
+		expectStatus(t, rec, http.StatusUnauthorized)
+	})
+
+	rec := serve(callback(t, "sub-1:fed-1@carvia.test", browser.state, browser.cookie))
+	expectStatus(t, rec, http.StatusOK)
+
+	claims, err := auth.ParseToken(decode[domain.TokenResponse](t, rec).Token)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if len(claims.AMR) != 1 || claims.AMR[0] != auth.AMRFederated {
+		t.Errorf("amr = %v, want federated login", claims.AMR)
+	}
+	if len(env.audit.eventsOf(domain.AuditRegistration, claims.UserID)) != 1 {
+		t.Error("federated registration audit event not recorded")
+	}
+
+	// Повторний вхід тією ж особою знаходить того самого користувача.
+	browser = startLogin(t)
+	rec = serve(callback(t, "sub-1:fed-1@carvia.test", browser.state, browser.cookie))
+	expectStatus(t, rec, http.StatusOK)
+	again, _ := auth.ParseToken(decode[domain.TokenResponse](t, rec).Token)
+	if again.UserID != claims.UserID {
+		t.Errorf("second login user = %d, want %d", again.UserID, claims.UserID)
+	}
+}
+
+func TestLinkAndUnlinkIdentity(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+	subject := fmt.Sprintf("link-%d", u.ID)
+
+	t.Run("RequiresRecentAuthentication", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/user_profile/identities/test", staleToken(t, u), nil)
+		expectStatus(t, rec, http.StatusUnauthorized)
+	})
+
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/user_profile/identities/nope", token, nil), http.StatusNotFound)
+
+	rec := call(t, http.MethodPost, "/api/sso/user_profile/identities/test", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	state := providerState(t, decode[domain.AuthURLResponse](t, rec).URL)
+	cookie := oauthStateCookie(t, rec)
+
+	// Жертва, якій підсунули посилання прив'язки, не прив'яже свій обліковий запис провайдера до чужого профілю.
+	t.Run("AnotherBrowser", func(t *testing.T) {
+		victim := startLogin(t)
+		expectStatus(t, serve(callback(t, "victim-"+subject+":", state, victim.cookie)), http.StatusUnauthorized)
+	})
+
+	expectStatus(t, serve(callback(t, subject+":", state, cookie)), http.StatusOK)
+
+	rec = call(t, http.MethodGet, "/api/sso/user_profile/identities", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	identities := decode[[]domain.UserIdentity](t, rec)
+	if len(identities) != 1 || identities[0].Provider != "test" {
+		t.Fatalf("identities = %+v, want the linked one", identities)
+	}
+
+	expectStatus(t, call(t, http.MethodDelete, "/api/sso/user_profile/identities/test", token, nil), http.StatusOK)
+	expectStatus(t, call(t, http.MethodDelete, "/api/sso/user_profile/identities/test", token, nil), http.StatusNotFound)
+}
+
+func TestSAMLUnknownPartner(t *testing.T) {
+	t.Parallel()
+
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/saml/acme/metadata", "", nil), http.StatusNotFound)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/saml/acme/login", "", nil), http.StatusNotFound)
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/saml/acme/acs", "", url.Values{"SAMLResponse": {"x"}}), http.StatusUnauthorized)
+}
+
+type memoryIdentities struct {
+	mu         sync.Mutex
+	identities []domain.UserIdentity
+}
+
+func (r *memoryIdentities) GetIdentity(ctx context.Context, provider, subject string) (domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return domain.UserIdentity{}, domain.ErrIdentityNotFound
+}
+
+func (r *memoryIdentities) ListIdentities(ctx context.Context, userID int) ([]domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identities := []domain.UserIdentity{}
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *memoryIdentities) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity.CreatedAt = time.Now()
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *memoryIdentities) UnlinkIdentity(ctx context.Context, userID int, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, identity := range r.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			r.identities = slices.Delete(r.identities, i, i+1)
+			return nil
+		}
+	}
+	return domain.ErrIdentityNotFound
+}
+
+type memoryOrganizations struct {
+	mu   sync.Mutex
+	orgs []domain.Organization
+}
+
+func (r *memoryOrganizations) GetOrganization(ctx context.Context, orgID int) (domain.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, org := range r.orgs {
+		if org.OrgID == orgID {
+			return org, nil
+		}
+	}
+	return domain.Organization{}, domain.ErrOrganizationNotFound
+}
+
+func (r *memoryOrganizations) EnsureOrganization(ctx context.Context, slug, name string) (domain.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, org := range r.orgs {
+		if org.Slug == slug {
+			return org, nil
+		}
+	}
+	org := domain.Organization{OrgID: len(r.orgs) + 1, Slug: slug, Name: name, CreatedAt: time.Now()}
+	r.orgs = append(r.orgs, org)
+	return org, nil
+}
+
+// fakeProvider — зовнішній провайдер, що підтверджує особу за кодом "<subject>:<email>".
+type fakeProvider struct{}
+
+func (fakeProvider) Name() string { return "test" }
+
+func (fakeProvider) AuthCodeURL(state, nonce string) string {
+	return "https://idp.test/authorize?state=" + state
+}
+
+func (fakeProvider) Exchange(ctx context.Context, code, nonce string) (federation.Identity, error) {
+	subject, email, ok := strings.Cut(code, ":")
+	if !ok {
+		return federation.Identity{}, errors.New("invalid code")
+	}
+	return federation.Identity{Provider: "test", Subject: subject, Email: email, EmailVerified: true, FirstName: "Fed"}, nil
+}
@@ -0,0 +1,375 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+	"strconv"
+	"time"
+)
+
+// OAuthServerHandler — ендпоінти, де SSO виступає OAuth2 authorization server для сторонніх клієнтів.
+type OAuthServerHandler struct {
+	clients    *service.ClientsService
+	deviceFlow *service.DeviceFlowService
+	consent    *service.ConsentService
+	exchange   *service.TokenExchangeService
+	users      *service.UsersService
+	sessions   *service.SessionsService
+	audit      *service.AuditService
+	tokenTTL   time.Duration
+}
+
+func NewOAuthServerHandler(clients *service.ClientsService, deviceFlow *service.DeviceFlowService, consent *service.ConsentService,
+	exchange *service.TokenExchangeService, users *service.UsersService, sessions *service.SessionsService, audit *service.AuditService,
+	tokenTTL time.Duration) *OAuthServerHandler {
+	return &OAuthServerHandler{
+		clients:    clients,
+		deviceFlow: deviceFlow,
+		consent:    consent,
+		exchange:   exchange,
+		users:      users,
+		sessions:   sessions,
+		audit:      audit,
+		tokenTTL:   tokenTTL,
+	}
+}
+
+// writeOAuthError відповідає у форматі RFC 6749 (error, error_description), а не problem+json:
+// його очікують OAuth-клієнти на token, device і introspection endpoint.
+func writeOAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	var oauthErr *domain.OAuthError
+	if !errors.As(err, &oauthErr) {
+		slog.DebugContext(r.Context(), "Внутрішня помилка OAuth", "err", err.Error())
+		responseHTTP.JSONResp(w, http.StatusInternalServerError, domain.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr == domain.ErrInvalidClient {
+		status = http.StatusUnauthorized
+	}
+
+	responseHTTP.JSONResp(w, status, domain.OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
+
+// authenticateClient бере облікові дані клієнта з HTTP Basic або з полів форми.
+func (h *OAuthServerHandler) authenticateClient(r *http.Request) (domain.OAuthClient, error) {
+	clientID, secret := clientCredentials(r)
+	return h.clients.Authenticate(clientID, secret)
+}
+
+func clientCredentials(r *http.Request) (clientID, secret string) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	return clientID, secret
+}
+
+func (h *OAuthServerHandler) DeviceCodeHandler(w http.ResponseWriter, r *http.Request) {
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	if !client.AllowsGrant(domain.GrantTypeDeviceCode) {
+		writeOAuthError(w, r, domain.ErrUnauthorizedClient)
+		return
+	}
+
+	scopes := domain.ParseScope(r.PostFormValue("scope"))
+	if err := h.consent.ValidateScopes(client, scopes); err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	resp, err := h.deviceFlow.Start(r.Context(), client, domain.JoinScopes(scopes))
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, resp)
+}
+
+// DeviceVerificationHandler повертає дані запиту пристрою для сторінки, де користувач вводить код.
+func (h *OAuthServerHandler) DeviceVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.deviceFlow.Pending(r.Context(), r.URL.Query().Get("user_code"))
+	if err != nil {
+		slog.DebugContext(r.Context(), "Запит пристрою не знайдено", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.InvalidCode)
+		return
+	}
+
+	client, _ := h.clients.Get(pending.ClientID)
+
+	scopes := []domain.ScopeInfo{}
+	for _, scope := range domain.ParseScope(pending.Scope) {
+		if info, ok := domain.LookupScope(scope); ok {
+			scopes = append(scopes, info)
+		}
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.DeviceVerificationResponse{
+		UserCode:   pending.UserCode,
+		ClientID:   pending.ClientID,
+		ClientName: client.Name,
+		Scope:      pending.Scope,
+		Scopes:     scopes,
+		ExpiresAt:  pending.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *OAuthServerHandler) DeviceApproveHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	var req domain.DeviceVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	pending, err := h.deviceFlow.Pending(r.Context(), req.UserCode)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Запит пристрою не знайдено", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.InvalidCode)
+		return
+	}
+
+	if err := h.deviceFlow.Decide(r.Context(), req.UserCode, userID, req.Approve); err != nil {
+		slog.DebugContext(r.Context(), "Помилка підтвердження пристрою", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.InvalidCode)
+		return
+	}
+
+	if req.Approve {
+		// Підтвердження пристрою — це і згода на запитані ним дозволи. Згода зберігається лише
+		// після рішення, щоб не лишитися для запиту, який так і не схвалено. Якщо зберегти її
+		// не вдалося, пристрій без згоди отримає access_denied, тож і тут підтвердження невдале.
+		if client, ok := h.clients.Get(pending.ClientID); ok && !client.Trusted {
+			scopes := domain.ParseScope(pending.Scope)
+			if err := h.consent.Grant(r.Context(), userID, client.ClientID, scopes); err != nil {
+				slog.ErrorContext(r.Context(), "Не вдалося зберегти згоду", "client_id", client.ClientID, "err", err.Error())
+				responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+				return
+			}
+			recordAudit(h.audit, r, domain.AuditConsentGranted, userID, userID, map[string]any{"client_id": client.ClientID, "scopes": scopes})
+		}
+		recordAudit(h.audit, r, domain.AuditDeviceAuthorized, userID, userID, map[string]any{"user_code": service.NormalizeUserCode(req.UserCode)})
+		responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.DeviceApproved)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditDeviceDenied, userID, userID, map[string]any{"user_code": service.NormalizeUserCode(req.UserCode)})
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.RequestDenied)
+}
+
+func (h *OAuthServerHandler) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	client, err := h.authenticateClient(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	grantType := r.PostFormValue("grant_type")
+	if !client.AllowsGrant(grantType) {
+		writeOAuthError(w, r, domain.ErrUnauthorizedClient)
+		return
+	}
+
+	switch grantType {
+	case domain.GrantTypeDeviceCode:
+		h.deviceCodeGrant(w, r, client)
+	case domain.GrantTypeTokenExchange:
+		h.tokenExchangeGrant(w, r, client)
+	default:
+		writeOAuthError(w, r, domain.ErrUnsupportedGrantType)
+	}
+}
+
+func (h *OAuthServerHandler) deviceCodeGrant(w http.ResponseWriter, r *http.Request, client domain.OAuthClient) {
+	deviceCode := r.PostFormValue("device_code")
+	if deviceCode == "" {
+		writeOAuthError(w, r, domain.ErrInvalidRequest)
+		return
+	}
+
+	approved, err := h.deviceFlow.Poll(r.Context(), client.ClientID, deviceCode)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), *approved.UserID)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	if err := h.users.EnsureActive(r.Context(), &user); err != nil {
+		writeOAuthError(w, r, domain.ErrAccessDenied)
+		return
+	}
+
+	// Токен отримує лише ті дозволи, на які користувач погодився.
+	scopes, err := h.consent.Effective(r.Context(), user.UserID, client, domain.ParseScope(approved.Scope))
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+	if !client.Trusted && len(scopes) == 0 {
+		writeOAuthError(w, r, domain.ErrAccessDenied)
+		return
+	}
+	scope := domain.JoinScopes(scopes)
+
+	session, err := h.sessions.Start(r.Context(), user.UserID, client.Name, clientIP(r), r.UserAgent())
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	claims := auth.JWTToken{
+		Username:  user.Login,
+		UserID:    user.UserID,
+		SessionID: session.ID,
+		ClientID:  client.ClientID,
+		Scope:     scope,
+	}
+	// Ролі користувача (зокрема admin) стороннім застосункам не передаються.
+	if client.Trusted {
+		claims.Roles = user.Roles()
+	}
+
+	// Строк задається явно, щоб expires_in збігався з exp у самому токені.
+	claims.ExpiresAt = time.Now().Add(h.tokenTTL).Unix()
+
+	token, err := auth.IssueToken(claims, h.tokenTTL)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditLoginSuccess, user.UserID, user.UserID,
+		map[string]any{"client_id": client.ClientID, "grant_type": domain.GrantTypeDeviceCode, "session_id": session.ID})
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(time.Unix(claims.ExpiresAt, 0)).Seconds()),
+		Scope:       scope,
+	})
+}
+
+// tokenExchangeGrant видає сервісу токен для виклику іншого сервісу від імені користувача.
+func (h *OAuthServerHandler) tokenExchangeGrant(w http.ResponseWriter, r *http.Request, client domain.OAuthClient) {
+	claims, err := h.exchange.Exchange(r.Context(), client, domain.TokenExchangeRequest{
+		SubjectToken:       r.PostFormValue("subject_token"),
+		SubjectTokenType:   r.PostFormValue("subject_token_type"),
+		Audience:           r.PostFormValue("audience"),
+		Scope:              r.PostFormValue("scope"),
+		RequestedTokenType: r.PostFormValue("requested_token_type"),
+	})
+	if err != nil {
+		slog.DebugContext(r.Context(), "Обмін токена відхилено", "client_id", client.ClientID, "err", err.Error())
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	token, err := auth.IssueToken(claims, h.tokenTTL)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditTokenExchanged, claims.UserID, claims.UserID, map[string]any{
+		"client_id": client.ClientID,
+		"audience":  claims.Audience,
+		"scope":     claims.Scope,
+	})
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.OAuthTokenResponse{
+		AccessToken:     token,
+		TokenType:       "Bearer",
+		ExpiresIn:       int(time.Until(time.Unix(claims.ExpiresAt, 0)).Seconds()),
+		Scope:           claims.Scope,
+		IssuedTokenType: domain.TokenTypeAccessToken,
+	})
+}
+
+// IntrospectHandler повідомляє сервісам, чи токен активний (RFC 7662). Потрібен для
+// непрозорих токенів і для перевірки відкликаних сесій, яку JWKS не дає.
+// Викликати його може лише конфіденційний клієнт (сервіс із секретом).
+func (h *OAuthServerHandler) IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	client, err := h.clients.AuthenticateConfidential(clientCredentials(r))
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	// Сервіс бачить активними і токени, звужені обміном саме до нього.
+	token, err := auth.ResolveTokenFor(r.Context(), r.PostFormValue("token"), client.ClientID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Неактивний токен в introspection", "client_id", client.ClientID, "err", err.Error())
+		responseHTTP.JSONResp(w, http.StatusOK, auth.IntrospectionResponse{Active: false})
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, auth.NewIntrospectionResponse(token))
+}
+
+// UserInfoHandler повертає claims користувача (OIDC UserInfo), відфільтровані за дозволами токена.
+// Токен без scope — від власного застосунку — бачить усе.
+func (h *OAuthServerHandler) UserInfoHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := auth.TokenFromContext(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні токена з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), token.UserID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні користувача", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	scopes := domain.ParseScope(token.Scope)
+	allowed := func(scope string) bool {
+		return token.Scope == "" || slices.Contains(scopes, scope)
+	}
+
+	claims := map[string]any{"sub": strconv.Itoa(user.UserID)}
+	if allowed(domain.ScopeProfile) {
+		claims["preferred_username"] = user.Login
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["picture"] = user.AvatarPath
+	}
+	if allowed(domain.ScopeEmail) {
+		claims["email"] = user.Email
+	}
+	if allowed(domain.ScopePhone) {
+		claims["phone_number"] = user.Phonenumber
+	}
+	if allowed(domain.ScopeAddress) {
+		claims["address"] = user.Address
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, claims)
+}
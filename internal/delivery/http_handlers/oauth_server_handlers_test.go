@@ -0,0 +1,377 @@
+package http_handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+func startDeviceFlow(t *testing.T, clientID, scope string) domain.DeviceCodeResponse {
+	t.Helper()
+
+	rec := call(t, http.MethodPost, "/api/sso/device/code", "", url.Values{"client_id": {clientID}, "scope": {scope}})
+	expectStatus(t, rec, http.StatusOK)
+	return decode[domain.DeviceCodeResponse](t, rec)
+}
+
+func pollDeviceToken(t *testing.T, clientID, deviceCode string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	// Інтервал опитування в тестовому середовищі — мілісекунда.
+	time.Sleep(5 * time.Millisecond)
+	return call(t, http.MethodPost, "/api/sso/token", "", url.Values{
+		"grant_type":  {domain.GrantTypeDeviceCode},
+		"client_id":   {clientID},
+		"device_code": {deviceCode},
+	})
+}
+
+func expectOAuthError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	expectStatus(t, rec, status)
+	if got := decode[domain.OAuthErrorResponse](t, rec).Error; got != code {
+		t.Fatalf("error = %q, want %q", got, code)
+	}
+}
+
+func TestDeviceCode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnknownClient", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/device/code", "", url.Values{"client_id": {"nope"}, "scope": {"profile"}})
+		expectOAuthError(t, rec, http.StatusUnauthorized, "invalid_client")
+	})
+
+	t.Run("GrantNotAllowed", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/device/code", "", url.Values{
+			"client_id": {"listing-service"}, "client_secret": {exchangeSecret}, "scope": {"profile"},
+		})
+		expectOAuthError(t, rec, http.StatusBadRequest, "unauthorized_client")
+	})
+
+	t.Run("ScopeOutsideClient", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/device/code", "", url.Values{"client_id": {"tv-app"}, "scope": {"inventory:write"}})
+		expectOAuthError(t, rec, http.StatusBadRequest, "invalid_scope")
+	})
+
+	resp := startDeviceFlow(t, "tv-app", "profile email")
+	if resp.DeviceCode == "" || resp.UserCode == "" || resp.VerificationURI == "" {
+		t.Errorf("device code response = %+v", resp)
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+	flow := startDeviceFlow(t, "tv-app", "profile")
+
+	expectOAuthError(t, pollDeviceToken(t, "tv-app", flow.DeviceCode), http.StatusBadRequest, "authorization_pending")
+
+	rec := call(t, http.MethodGet, "/api/sso/device/verify?user_code="+url.QueryEscape(flow.UserCode), token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if pending := decode[domain.DeviceVerificationResponse](t, rec); pending.ClientID != "tv-app" || pending.Scope != "profile" {
+		t.Errorf("verification = %+v, want tv-app asking for profile", pending)
+	}
+
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/device/verify?user_code=XXXX-XXXX", token, nil), http.StatusNotFound)
+
+	rec = call(t, http.MethodPost, "/api/sso/device/verify", token, domain.DeviceVerifyRequest{UserCode: flow.UserCode, Approve: true})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = pollDeviceToken(t, "tv-app", flow.DeviceCode)
+	expectStatus(t, rec, http.StatusOK)
+	issued := decode[domain.OAuthTokenResponse](t, rec)
+	if issued.TokenType != "Bearer" || issued.Scope != "profile" {
+		t.Errorf("token response = %+v, want bearer token with profile scope", issued)
+	}
+	claims, err := auth.ParseToken(issued.AccessToken)
+	if err != nil {
+		t.Fatalf("issued token: %v", err)
+	}
+	// expires_in повідомляє справжній строк дії токена.
+	left := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if diff := time.Duration(issued.ExpiresIn)*time.Second - left; diff < -time.Second || diff > time.Second {
+		t.Errorf("expires_in = %d, token expires in %s", issued.ExpiresIn, left)
+	}
+
+	// Код пристрою одноразовий.
+	expectOAuthError(t, pollDeviceToken(t, "tv-app", flow.DeviceCode), http.StatusBadRequest, "invalid_grant")
+
+	t.Run("UserInfoFollowsScope", func(t *testing.T) {
+		rec := call(t, http.MethodGet, "/api/sso/userinfo", issued.AccessToken, nil)
+		expectStatus(t, rec, http.StatusOK)
+		claims := decode[map[string]any](t, rec)
+		if claims["sub"] != strconv.Itoa(u.ID) || claims["preferred_username"] != u.Login {
+			t.Errorf("userinfo = %v, want profile claims", claims)
+		}
+		if _, ok := claims["email"]; ok {
+			t.Errorf("userinfo exposes email without the email scope: %v", claims)
+		}
+	})
+
+	t.Run("NoFirstPartyAccess", func(t *testing.T) {
+		for _, route := range []struct{ method, path string }{
+			{http.MethodPut, "/api/sso/update_user_profile"},
+			{http.MethodPost, "/api/sso/refresh"},
+			{http.MethodGet, "/api/sso/sessions"},
+		} {
+			if rec := call(t, route.method, route.path, issued.AccessToken, nil); rec.Code != http.StatusForbidden {
+				t.Errorf("%s %s with a client token: status = %d, want 403", route.method, route.path, rec.Code)
+			}
+		}
+	})
+
+	t.Run("ConsentNotSaved", func(t *testing.T) {
+		other := newUser(t, "user")
+		env.consents.failFor(other.ID)
+		flow := startDeviceFlow(t, "tv-app", "profile")
+
+		rec := call(t, http.MethodPost, "/api/sso/device/verify", login(t, other), domain.DeviceVerifyRequest{UserCode: flow.UserCode, Approve: true})
+		expectStatus(t, rec, http.StatusInternalServerError)
+		expectOAuthError(t, pollDeviceToken(t, "tv-app", flow.DeviceCode), http.StatusBadRequest, "access_denied")
+	})
+
+	// Згода не зберігається для запиту, який не вдалося схвалити.
+	t.Run("NoConsentWithoutDecision", func(t *testing.T) {
+		other := newUser(t, "user")
+		otherToken := login(t, other)
+		env.deviceAuth.failFor(other.ID)
+		flow := startDeviceFlow(t, "tv-app", "profile")
+
+		rec := call(t, http.MethodPost, "/api/sso/device/verify", otherToken, domain.DeviceVerifyRequest{UserCode: flow.UserCode, Approve: true})
+		expectStatus(t, rec, http.StatusNotFound)
+		if consents := decode[[]domain.ConsentGrant](t, call(t, http.MethodGet, "/api/sso/user_profile/consents", otherToken, nil)); len(consents) != 0 {
+			t.Errorf("consents = %+v, want none for a request that was not approved", consents)
+		}
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		denied := startDeviceFlow(t, "tv-app", "profile")
+		rec := call(t, http.MethodPost, "/api/sso/device/verify", token, domain.DeviceVerifyRequest{UserCode: denied.UserCode})
+		expectStatus(t, rec, http.StatusOK)
+		expectOAuthError(t, pollDeviceToken(t, "tv-app", denied.DeviceCode), http.StatusBadRequest, "access_denied")
+	})
+}
+
+// TestDeviceFlowAdmin перевіряє, що адміністратор, який підтвердив сторонній пристрій, не передає йому свої права.
+func TestDeviceFlowAdmin(t *testing.T) {
+	t.Parallel()
+
+	admin := newUser(t, "admin")
+	token := login(t, admin)
+	flow := startDeviceFlow(t, "tv-app", "profile")
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/device/verify", token, domain.DeviceVerifyRequest{UserCode: flow.UserCode, Approve: true}), http.StatusOK)
+
+	rec := pollDeviceToken(t, "tv-app", flow.DeviceCode)
+	expectStatus(t, rec, http.StatusOK)
+	issued := decode[domain.OAuthTokenResponse](t, rec)
+
+	claims, err := auth.ParseToken(issued.AccessToken)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if len(claims.Roles) != 0 {
+		t.Errorf("client token roles = %v, want none", claims.Roles)
+	}
+
+	target := newUser(t, "user")
+	rec = call(t, http.MethodPost, "/api/sso/admin/users/"+strconv.Itoa(target.ID)+"/suspend", issued.AccessToken,
+		domain.SuspendRequest{Reason: "spam"})
+	expectStatus(t, rec, http.StatusForbidden)
+}
+
+func TestTokenExchange(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	subject := login(t, u)
+
+	exchange := func(audience string) *httptest.ResponseRecorder {
+		req := newRequest(t, http.MethodPost, "/api/sso/token", url.Values{
+			"grant_type":         {domain.GrantTypeTokenExchange},
+			"subject_token":      {subject},
+			"subject_token_type": {domain.TokenTypeAccessToken},
+			"audience":           {audience},
+			"scope":              {domain.ScopeListingsRead},
+		})
+		req.SetBasicAuth("listing-service", exchangeSecret)
+		return serve(req)
+	}
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		req := newRequest(t, http.MethodPost, "/api/sso/token", url.Values{"grant_type": {domain.GrantTypeTokenExchange}})
+		req.SetBasicAuth("listing-service", "wrong")
+		expectOAuthError(t, serve(req), http.StatusUnauthorized, "invalid_client")
+	})
+
+	t.Run("UnknownAudience", func(t *testing.T) {
+		expectOAuthError(t, exchange("billing-service"), http.StatusBadRequest, "invalid_target")
+	})
+
+	rec := exchange("messaging-service")
+	expectStatus(t, rec, http.StatusOK)
+	issued := decode[domain.OAuthTokenResponse](t, rec)
+	if issued.IssuedTokenType != domain.TokenTypeAccessToken {
+		t.Errorf("issued_token_type = %q", issued.IssuedTokenType)
+	}
+	if issued.Scope != domain.ScopeListingsRead {
+		t.Errorf("scope = %q, want %q", issued.Scope, domain.ScopeListingsRead)
+	}
+
+	claims, err := auth.ParseTokenFor(issued.AccessToken, "messaging-service")
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if claims.UserID != u.ID || claims.Audience != "messaging-service" || claims.Act == nil || claims.Act.ClientID != "listing-service" {
+		t.Errorf("exchanged claims = %+v, want user %d for messaging-service acting as listing-service", claims, u.ID)
+	}
+	if len(claims.Roles) != 0 {
+		t.Errorf("exchanged token roles = %v, want none", claims.Roles)
+	}
+
+	// Звужений токен чинний лише для свого сервісу.
+	t.Run("Audience", func(t *testing.T) {
+		if _, err := auth.ParseToken(issued.AccessToken); err == nil {
+			t.Errorf("SSO accepted a token narrowed to messaging-service")
+		}
+		verify := "/api/sso/auth/verify?scope=" + url.QueryEscape(domain.ScopeListingsRead)
+		expectStatus(t, call(t, http.MethodGet, verify, issued.AccessToken, nil), http.StatusUnauthorized)
+		expectStatus(t, call(t, http.MethodGet, verify+"&audience=billing-service", issued.AccessToken, nil), http.StatusUnauthorized)
+
+		rec := call(t, http.MethodGet, verify+"&audience=messaging-service", issued.AccessToken, nil)
+		expectStatus(t, rec, http.StatusOK)
+		if got := rec.Header().Get("X-Token-Audience"); got != "messaging-service" {
+			t.Errorf("X-Token-Audience = %q, want messaging-service", got)
+		}
+	})
+}
+
+func TestIntrospect(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	introspect := func(token string) auth.IntrospectionResponse {
+		req := newRequest(t, http.MethodPost, "/api/sso/introspect", url.Values{"token": {token}})
+		req.SetBasicAuth("listing-service", exchangeSecret)
+		rec := serve(req)
+		expectStatus(t, rec, http.StatusOK)
+		return decode[auth.IntrospectionResponse](t, rec)
+	}
+
+	t.Run("RequiresClient", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/introspect", "", url.Values{"token": {token}})
+		expectOAuthError(t, rec, http.StatusUnauthorized, "invalid_client")
+	})
+
+	t.Run("RejectsPublicClient", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/introspect", "", url.Values{"token": {token}, "client_id": {"tv-app"}})
+		expectOAuthError(t, rec, http.StatusUnauthorized, "invalid_client")
+	})
+
+	if resp := introspect(token); !resp.Active {
+		t.Fatalf("introspection of a live token = %+v", resp)
+	}
+	if resp := introspect("garbage"); resp.Active {
+		t.Errorf("garbage token reported active")
+	}
+
+	// Відкликана сесія робить токен неактивним, хоча підпис і строк ще чинні.
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/session/logout", token, nil), http.StatusOK)
+	if resp := introspect(token); resp.Active {
+		t.Errorf("token of a revoked session reported active")
+	}
+}
+
+type memoryDeviceAuth struct {
+	mu    sync.Mutex
+	auths map[string]domain.DeviceAuthorization
+	// failing — користувачі, чиє рішення щодо пристрою завершується помилкою бази.
+	failing map[int]bool
+}
+
+func (r *memoryDeviceAuth) failFor(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failing[userID] = true
+}
+
+func (r *memoryDeviceAuth) CreateDeviceAuthorization(ctx context.Context, auth domain.DeviceAuthorization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auth.CreatedAt = time.Now()
+	r.auths[auth.DeviceCodeHash] = auth
+	return nil
+}
+
+func (r *memoryDeviceAuth) GetByDeviceCode(ctx context.Context, deviceCodeHash string) (domain.DeviceAuthorization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auth, ok := r.auths[deviceCodeHash]
+	if !ok {
+		return auth, domain.ErrDeviceAuthorizationNotFound
+	}
+	return auth, nil
+}
+
+func (r *memoryDeviceAuth) GetByUserCode(ctx context.Context, userCode string) (domain.DeviceAuthorization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, auth := range r.auths {
+		if auth.UserCode == userCode {
+			return auth, nil
+		}
+	}
+	return domain.DeviceAuthorization{}, domain.ErrDeviceAuthorizationNotFound
+}
+
+func (r *memoryDeviceAuth) DecideDeviceAuthorization(ctx context.Context, userCode string, status domain.DeviceAuthorizationStatus, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failing[userID] {
+		return errors.New("device authorization storage unavailable")
+	}
+	for hash, auth := range r.auths {
+		if auth.UserCode == userCode && auth.Status == domain.DeviceAuthPending && time.Now().Before(auth.ExpiresAt) {
+			auth.Status = status
+			auth.UserID = &userID
+			r.auths[hash] = auth
+			return nil
+		}
+	}
+	return domain.ErrDeviceAuthorizationNotFound
+}
+
+func (r *memoryDeviceAuth) RecordDevicePoll(ctx context.Context, deviceCodeHash string, polledAt time.Time, interval time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auth := r.auths[deviceCodeHash]
+	auth.LastPolledAt = &polledAt
+	auth.Interval = interval
+	r.auths[deviceCodeHash] = auth
+	return nil
+}
+
+func (r *memoryDeviceAuth) ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	auth, ok := r.auths[deviceCodeHash]
+	if !ok || auth.Status != domain.DeviceAuthApproved {
+		return false, nil
+	}
+	auth.Status = domain.DeviceAuthConsumed
+	r.auths[deviceCodeHash] = auth
+	return true, nil
+}
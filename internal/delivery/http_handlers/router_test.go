@@ -0,0 +1,450 @@
+package http_handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sso-service/internal/delivery/http_handlers"
+	"sso-service/internal/domain"
+	"sso-service/internal/federation"
+	"sso-service/internal/lib/clientip"
+	"sso-service/internal/lib/logger"
+	"sso-service/internal/lib/requestid"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/repository"
+	"sso-service/internal/server"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+)
+
+const (
+	tokenTTL      = time.Hour
+	stepUpAge     = 10 * time.Minute
+	sessionMaxAge = 24 * time.Hour
+
+	exchangeSecret = "listing-secret"
+
+	// clientAddr — адреса, з якої приходять тестові запити; gatewayAddr — довірений проксі.
+	clientAddr  = "203.0.113.10"
+	gatewayAddr = "198.51.100.7"
+)
+
+// testEnv — увесь сервіс на сховищах у пам'яті, зібраний так само, як в app.Run.
+// pkg/auth тримає ключі та перевірки токенів у глобальному стані, тому середовище одне на пакет.
+type testEnv struct {
+	router http.Handler
+
+	users      *repository.MemoryUserRepo
+	sessions   *memorySessions
+	audit      *memoryAudit
+	consents   *memoryConsents
+	deviceAuth *memoryDeviceAuth
+	mail       *captureMailer
+
+	usersService *service.UsersService
+	keys         *auth.KeySet
+}
+
+var env *testEnv
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	keysDir, err := os.MkdirTemp("", "sso-keys")
+	if err != nil {
+		panic(err)
+	}
+
+	env, err = newTestEnv(keysDir)
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(keysDir)
+	os.Exit(code)
+}
+
+func newTestEnv(keysDir string) (*testEnv, error) {
+	if _, err := auth.GenerateSigningKey(keysDir); err != nil {
+		return nil, err
+	}
+	keys, err := auth.LoadKeySet(keysDir, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	auth.UseKeySet(keys)
+	auth.EnableCookieAuth()
+
+	// Решта репозиторіїв — прості сховища в пам'яті, що лежать поруч із тестами своїх функцій:
+	// обробники тестуються без бази.
+	e := &testEnv{
+		users:      repository.NewMemoryUserRepo(),
+		sessions:   &memorySessions{sessions: map[string]domain.Session{}},
+		audit:      &memoryAudit{},
+		consents:   &memoryConsents{grants: map[string]domain.ConsentGrant{}, failing: map[int]bool{}},
+		deviceAuth: &memoryDeviceAuth{auths: map[string]domain.DeviceAuthorization{}, failing: map[int]bool{}},
+		mail:       &captureMailer{},
+		keys:       keys,
+	}
+
+	notifications := service.NewNotificationsService(e.mail, "http://sso.test")
+	e.usersService = service.NewUsersService(e.users, notifications, "http://storage.test")
+	auditService := service.NewAuditService(e.audit)
+	usedTokens := &memoryUsedTokens{used: map[string]time.Time{}}
+	sessionsService := service.NewSessionsService(e.sessions, usedTokens, sessionMaxAge)
+	devicesService := service.NewDevicesService(&memoryDevices{}, usedTokens, notifications)
+
+	providers := map[string]federation.Provider{"test": fakeProvider{}}
+	federationService := service.NewFederationService(providers, nil, &memoryIdentities{}, e.users, &memoryOrganizations{},
+		usedTokens)
+
+	clientsService := service.NewClientsService([]domain.OAuthClient{
+		{
+			ClientID:   "tv-app",
+			Name:       "CarVia TV",
+			Public:     true,
+			GrantTypes: []string{domain.GrantTypeDeviceCode},
+			Scopes:     []string{domain.ScopeProfile, domain.ScopeEmail},
+		},
+		{
+			ClientID:   "listing-service",
+			Name:       "Listing service",
+			Secret:     exchangeSecret,
+			GrantTypes: []string{domain.GrantTypeTokenExchange},
+			Trusted:    true,
+			Audiences:  []string{"messaging-service"},
+		},
+	})
+	consentService := service.NewConsentService(e.consents, clientsService)
+	deviceFlowService := service.NewDeviceFlowService(e.deviceAuth,
+		"http://sso.test/device", 10*time.Minute, time.Millisecond)
+	accessTokensService := service.NewAccessTokensService(&memoryAccessTokens{}, e.users)
+
+	auth.RegisterOpaqueTokenResolver(domain.PersonalTokenPrefix, accessTokensService.Resolve)
+	auth.RegisterTokenCheck(func(ctx context.Context, token *auth.JWTToken) error {
+		return e.usersService.CheckUserStatus(ctx, token.UserID)
+	})
+	auth.RegisterTokenCheck(func(ctx context.Context, token *auth.JWTToken) error {
+		if token.SessionID == "" {
+			return nil
+		}
+		return sessionsService.Validate(ctx, token.SessionID, token.UserID)
+	})
+	auth.RegisterTokenCheck(consentService.CheckToken)
+	auth.RegisterErrorWriter(responseHTTP.AuthError)
+	auth.RegisterAuthenticatedHook(http_handlers.LogAuthenticatedUser)
+
+	cookies := http_handlers.NewCookieSessions(http_handlers.CookieOptions{
+		Enabled:    true,
+		SameSite:   http.SameSiteLaxMode,
+		RefreshTTL: 24 * time.Hour,
+	}, tokenTTL)
+
+	proxies, err := clientip.NewResolver([]string{"198.51.100.0/24"})
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := server.Handlers{
+		Users: http_handlers.NewUsersHandler(e.usersService, sessionsService, devicesService, auditService, cookies,
+			tokenTTL, 15*time.Minute),
+		Audit:    http_handlers.NewAuditHandler(auditService),
+		Sessions: http_handlers.NewSessionsHandler(sessionsService, auditService),
+		Security: http_handlers.NewSecurityHandler(e.usersService, sessionsService, devicesService, auditService),
+		OAuth:    http_handlers.NewOAuthHandler(federationService, e.usersService, sessionsService, auditService, cookies, tokenTTL),
+		OAuthServer: http_handlers.NewOAuthServerHandler(clientsService, deviceFlowService, consentService,
+			service.NewTokenExchangeService(5*time.Minute), e.usersService, sessionsService, auditService, tokenTTL),
+		AccessTokens: http_handlers.NewAccessTokensHandler(accessTokensService, auditService),
+		Consent:      http_handlers.NewConsentHandler(consentService, auditService),
+		Keys:         http_handlers.NewKeysHandler(keys),
+		ForwardAuth:  http_handlers.NewForwardAuthHandler(service.NewForwardAuthService(time.Second)),
+	}
+
+	e.router = server.NewRouter(handlers, auth.StepUpPolicy{MaxAge: stepUpAge}, proxies)
+
+	return e, nil
+}
+
+var userSeq atomic.Int64
+
+type testUser struct {
+	ID       int
+	Login    string
+	Email    string
+	Password string
+}
+
+// newUser реєструє користувача напряму через UsersService; role — "user" або "admin".
+func newUser(t *testing.T, role string) testUser {
+	t.Helper()
+
+	n := userSeq.Add(1)
+	req := domain.RegisterRequest{
+		Login:       fmt.Sprintf("user%d", n),
+		Email:       fmt.Sprintf("user%d@carvia.test", n),
+		Password:    fmt.Sprintf("password-%d", n),
+		FirstName:   "Тарас",
+		LastName:    "Мельник",
+		Phonenumber: "+380501112233",
+		Address:     "Одеса",
+	}
+
+	var err error
+	if role == "admin" {
+		err = env.usersService.CreateAdmin(context.Background(), &req)
+	} else {
+		err = env.usersService.CreateUser(context.Background(), &req)
+	}
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	return testUser{ID: req.UserID, Login: req.Login, Email: req.Email, Password: req.Password}
+}
+
+// newRequest будує запит; body може бути nil, url.Values (форма), string (сире тіло) або значенням для JSON.
+func newRequest(t *testing.T, method, path string, body any) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		reader = strings.NewReader(b.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case string:
+		reader = strings.NewReader(b)
+		contentType = "application/json"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.RemoteAddr = clientAddr + ":5555"
+	return req
+}
+
+func serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// call виконує запит з токеном (якщо він не порожній).
+func call(t *testing.T, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := newRequest(t, method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return serve(req)
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %T: %v; body: %s", v, err, rec.Body.String())
+	}
+	return v
+}
+
+// login входить паролем і повертає токен.
+func login(t *testing.T, u testUser) string {
+	t.Helper()
+
+	rec := call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{Email: u.Email, Password: u.Password})
+	expectStatus(t, rec, http.StatusOK)
+	return decode[domain.TokenResponse](t, rec).Token
+}
+
+// staleToken — токен чинної сесії, автентифікованої давніше за політику step-up.
+func staleToken(t *testing.T, u testUser) string {
+	t.Helper()
+
+	claims, err := auth.ParseToken(login(t, u))
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	claims.SetAuthContext(time.Now().Add(-2*stepUpAge), claims.AMR)
+	claims.ExpiresAt = 0
+
+	token, err := auth.IssueToken(*claims, tokenTTL)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func TestRouterNotFoundAndMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	req := newRequest(t, http.MethodGet, "/api/sso/nope", nil)
+	req.Header.Set(requestid.Header, "gw-1")
+	rec := serve(req)
+	expectStatus(t, rec, http.StatusNotFound)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, responseHTTP.ProblemContentType) {
+		t.Errorf("Content-Type = %q, want %s", ct, responseHTTP.ProblemContentType)
+	}
+	resp := decode[responseHTTP.Problem](t, rec)
+	if resp.Code != responseHTTP.CodeNotFound || resp.Status != http.StatusNotFound || resp.Instance != "/api/sso/nope" || resp.RequestID != "gw-1" {
+		t.Errorf("problem = %+v, want not_found for /api/sso/nope with request id", resp)
+	}
+
+	rec = call(t, http.MethodGet, "/api/sso/login", "", nil)
+	expectStatus(t, rec, http.StatusMethodNotAllowed)
+	if resp = decode[responseHTTP.Problem](t, rec); resp.Code != responseHTTP.CodeMethodNotAllowed {
+		t.Errorf("error code = %q, want %q", resp.Code, responseHTTP.CodeMethodNotAllowed)
+	}
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	t.Parallel()
+
+	messageIn := func(acceptLanguage, path string) string {
+		t.Helper()
+		req := newRequest(t, http.MethodGet, path, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		return decode[responseHTTP.Problem](t, serve(req)).Detail
+	}
+
+	tests := []struct{ lang, path, want string }{
+		{"", "/api/sso/nope", "Маршрут не знайдено"},
+		{"en-US,en;q=0.9", "/api/sso/nope", "Route not found"},
+		{"", "/api/sso/user_profile", "Не авторизовано"},
+		{"en", "/api/sso/user_profile", "Unauthorized"},
+	}
+	for _, tt := range tests {
+		if got := messageIn(tt.lang, tt.path); got != tt.want {
+			t.Errorf("GET %s with Accept-Language %q: message = %q, want %q", tt.path, tt.lang, got, tt.want)
+		}
+	}
+
+	t.Run("FieldErrors", func(t *testing.T) {
+		req := newRequest(t, http.MethodPost, "/api/sso/login", domain.LoginRequest{Email: "ivan@carvia.test"})
+		req.Header.Set("Accept-Language", "en")
+		rec := serve(req)
+		expectStatus(t, rec, http.StatusBadRequest)
+
+		resp := decode[responseHTTP.Problem](t, rec)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "This field is required" {
+			t.Errorf("fields = %+v, want English required error on password", resp.Errors)
+		}
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct{ header, want string }{
+		{"gw-7", "gw-7"},
+		{"", ""},
+		{"bad id", ""},
+	}
+	for _, tt := range tests {
+		req := newRequest(t, http.MethodGet, "/api/sso/.well-known/jwks.json", nil)
+		if tt.header != "" {
+			req.Header.Set(requestid.Header, tt.header)
+		}
+
+		id := serve(req).Header().Get(requestid.Header)
+		if tt.want != "" && id != tt.want {
+			t.Errorf("%s for header %q = %q, want %q", requestid.Header, tt.header, id, tt.want)
+		}
+		if tt.want == "" && (id == "" || id == tt.header) {
+			t.Errorf("%s for header %q = %q, want a generated one", requestid.Header, tt.header, id)
+		}
+	}
+}
+
+// TestAccessLog підміняє глобальний logger, тому не паралельний.
+func TestAccessLog(t *testing.T) {
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	logger.InitGlobalLogger(&out, slog.LevelInfo)
+
+	req := newRequest(t, http.MethodGet, "/api/sso/user_profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(requestid.Header, "gw-access")
+	req.Header.Set("X-Forwarded-For", "192.0.2.66, 203.0.113.9")
+	req.RemoteAddr = gatewayAddr + ":443"
+	expectStatus(t, serve(req), http.StatusOK)
+
+	line := out.String()
+	for _, want := range []string{
+		"HTTP-запит",
+		`"method": "GET"`,
+		`"route": "/api/sso/user_profile"`,
+		`"status": 200`,
+		fmt.Sprintf(`"user_id": %d`, u.ID),
+		`"ip": "203.0.113.9"`,
+		`"request_id": "gw-access"`,
+		`"latency_ms"`,
+		`"bytes"`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("access log lacks %s: %s", want, line)
+		}
+	}
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	t.Parallel()
+
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/api/sso/user_profile"},
+		{http.MethodPut, "/api/sso/update_user_profile"},
+		{http.MethodGet, "/api/sso/sessions"},
+		{http.MethodGet, "/api/sso/tokens"},
+		{http.MethodPost, "/api/sso/tokens"},
+		{http.MethodGet, "/api/sso/user_profile/consents"},
+		{http.MethodGet, "/api/sso/userinfo"},
+		{http.MethodGet, "/api/sso/admin/audit"},
+		{http.MethodPost, "/api/sso/refresh"},
+	}
+	for _, route := range routes {
+		rec := call(t, route.method, route.path, "", nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without token: status = %d, want 401", route.method, route.path, rec.Code)
+		}
+		rec = call(t, route.method, route.path, "garbage", nil)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s with invalid token: status = %d, want 401", route.method, route.path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package http_handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *OAuthHandler) SAMLMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	partner := mux.Vars(r)["partner"]
+
+	metadata, err := h.federation.SAMLMetadata(partner)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при формуванні SAML метаданих", "partner", partner, "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.PartnerNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(metadata)
+}
+
+func (h *OAuthHandler) SAMLLoginHandler(w http.ResponseWriter, r *http.Request) {
+	partner := mux.Vars(r)["partner"]
+
+	authURL, err := h.federation.SAMLAuthURL(partner)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при формуванні SAML запиту", "partner", partner, "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.PartnerNotFound)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// SAMLACSHandler — Assertion Consumer Service: приймає відповідь IdP і видає наші звичайні токени.
+func (h *OAuthHandler) SAMLACSHandler(w http.ResponseWriter, r *http.Request) {
+	partner := mux.Vars(r)["partner"]
+
+	result, err := h.federation.CompleteSAML(r.Context(), partner, r)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка SAML входу", "partner", partner, "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, 0, map[string]any{"provider": "saml:" + partner, "reason": "federation"})
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.SAMLLoginFailed)
+		return
+	}
+
+	user := result.User
+
+	if result.Created {
+		recordAudit(h.audit, r, domain.AuditRegistration, user.UserID, user.UserID, map[string]any{"provider": "saml:" + partner})
+	}
+	if result.Linked {
+		recordAudit(h.audit, r, domain.AuditIdentityLinked, user.UserID, user.UserID, map[string]any{"provider": "saml:" + partner})
+	}
+
+	if err := h.users.EnsureActive(r.Context(), &user); err != nil {
+		slog.DebugContext(r.Context(), "Вхід заборонено статусом користувача", "user_id", user.UserID, "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, user.UserID, map[string]any{"provider": "saml:" + partner, "reason": "status"})
+		writeStatusError(w, r, err)
+		return
+	}
+
+	token, sessionID, err := issueSessionToken(r, h.sessions, h.tokenTTL, user, partner, []string{auth.AMRFederated})
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditLoginSuccess, user.UserID, user.UserID,
+		map[string]any{"provider": "saml:" + partner, "session_id": sessionID})
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.TokenResponse{Token: token})
+}
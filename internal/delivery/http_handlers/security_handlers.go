@@ -0,0 +1,171 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+)
+
+type SecurityHandler struct {
+	users    *service.UsersService
+	sessions *service.SessionsService
+	devices  *service.DevicesService
+	audit    *service.AuditService
+}
+
+func NewSecurityHandler(users *service.UsersService, sessions *service.SessionsService, devices *service.DevicesService,
+	audit *service.AuditService) *SecurityHandler {
+	return &SecurityHandler{
+		users:    users,
+		sessions: sessions,
+		devices:  devices,
+		audit:    audit,
+	}
+}
+
+// notMePage — сторінка, на яку веде посилання "це був не я" з листа. Token заповнений лише на сторінці
+// підтвердження: сама дія виконується POST-запитом форми, бо GET посилання з листа відкривають
+// ще й поштові сканери та попередній перегляд.
+var notMePage = template.Must(template.New("not_me").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Token}}<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Submit}}</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+func renderNotMePage(w http.ResponseWriter, r *http.Request, status int, message i18n.Key, token string) {
+	lang := i18n.FromRequest(r)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// Токен у URL не має витекти через Referer, а кнопку не можна підсунути в чужий фрейм.
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; form-action 'self'; frame-ancestors 'none'")
+	w.WriteHeader(status)
+
+	err := notMePage.Execute(w, map[string]string{
+		"Lang":    string(lang),
+		"Title":   i18n.T(lang, i18n.NotMeTitle),
+		"Message": i18n.T(lang, message),
+		"Submit":  i18n.T(lang, i18n.NotMeSubmit),
+		"Token":   token,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Не вдалося показати сторінку звіту про вхід", "err", err.Error())
+	}
+}
+
+// NotMePageHandler показує сторінку підтвердження для посилання "це був не я" з листа про новий вхід.
+// Сам GET нічого не змінює.
+func (h *SecurityHandler) NotMePageHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if _, err := h.devices.ParseReport(token); err != nil {
+		slog.DebugContext(r.Context(), "Неправильний токен звіту про вхід", "err", err.Error())
+		renderNotMePage(w, r, http.StatusBadRequest, i18n.InvalidLink, "")
+		return
+	}
+
+	renderNotMePage(w, r, http.StatusOK, i18n.NotMeConfirm, token)
+}
+
+// NotMeHandler обробляє підтвердження "це був не я": завершує підозрілу сесію і вимагає скидання пароля.
+// Токен одноразовий.
+func (h *SecurityHandler) NotMeHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.devices.ConsumeReport(r.Context(), r.FormValue("token"))
+	if errors.Is(err, domain.ErrUnauthorized) {
+		slog.DebugContext(r.Context(), "Неправильний токен звіту про вхід", "err", err.Error())
+		renderNotMePage(w, r, http.StatusBadRequest, i18n.InvalidLink, "")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Не вдалося використати токен звіту про вхід", "err", err.Error())
+		renderNotMePage(w, r, http.StatusInternalServerError, i18n.InternalError, "")
+		return
+	}
+
+	if claims.SessionID != "" {
+		if err := h.sessions.Revoke(r.Context(), claims.UserID, claims.SessionID); err != nil {
+			slog.DebugContext(r.Context(), "Сесію вже завершено", "session_id", claims.SessionID, "err", err.Error())
+		} else {
+			recordAudit(h.audit, r, domain.AuditTokenRevoked, claims.UserID, claims.UserID,
+				map[string]any{"session_id": claims.SessionID, "reason": "reported"})
+		}
+	}
+
+	if err := h.users.ForcePasswordReset(r.Context(), claims.UserID); err != nil {
+		slog.ErrorContext(r.Context(), "Не вдалося ініціювати скидання пароля", "user_id", claims.UserID, "err", err.Error())
+		renderNotMePage(w, r, http.StatusInternalServerError, i18n.InternalError, "")
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditLoginReported, claims.UserID, claims.UserID, map[string]any{"session_id": claims.SessionID})
+
+	renderNotMePage(w, r, http.StatusOK, i18n.SessionEndedCheckEmail, "")
+}
+
+func (h *SecurityHandler) RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	// Відповідь однакова незалежно від існування email, щоб не розкривати зареєстровані адреси.
+	if err := h.users.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		slog.DebugContext(r.Context(), "Скидання пароля не надіслано", "err", err.Error())
+	}
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.PasswordResetSent)
+}
+
+func (h *SecurityHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		responseHTTP.Error(w, r, err, i18n.CheckFields)
+		return
+	}
+
+	// Недійсне чи вже використане посилання — помилка користувача; збій бази так не маскується.
+	userID, err := h.users.ResetPassword(r.Context(), req.Token, req.Password)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка скидання пароля", "err", err.Error())
+		if errors.Is(err, domain.ErrUnauthorized) {
+			responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidLink)
+			return
+		}
+		responseHTTP.Error(w, r, err, userErrorMessage(err))
+		return
+	}
+
+	if err := h.sessions.RevokeAll(r.Context(), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Не вдалося відкликати сесії після скидання пароля", "user_id", userID, "err", err.Error())
+	}
+
+	recordAudit(h.audit, r, domain.AuditPasswordReset, userID, userID, nil)
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.PasswordChanged)
+}
@@ -0,0 +1,230 @@
+package http_handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/mailer"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+)
+
+// resetTokenFromMail дістає токен скидання пароля з останнього листа користувачу.
+func resetTokenFromMail(t *testing.T, email string) string {
+	t.Helper()
+
+	msg, ok := env.mail.last(email)
+	if !ok {
+		t.Fatalf("no mail sent to %s", email)
+	}
+	lines := strings.Split(msg.Body, "\n")
+	for i, line := range lines {
+		if strings.HasSuffix(line, ":") && i+1 < len(lines) {
+			return strings.TrimSpace(lines[i+1])
+		}
+	}
+	t.Fatalf("no token in mail body: %s", msg.Body)
+	return ""
+}
+
+func TestPasswordReset(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	oldToken := login(t, u)
+
+	t.Run("UnknownEmailLooksTheSame", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/password_reset/request", "", domain.PasswordResetRequest{Email: "ghost@carvia.test"})
+		expectStatus(t, rec, http.StatusOK)
+	})
+
+	rec := call(t, http.MethodPost, "/api/sso/password_reset/request", "", domain.PasswordResetRequest{Email: u.Email})
+	expectStatus(t, rec, http.StatusOK)
+	token := resetTokenFromMail(t, u.Email)
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/password_reset", "", domain.PasswordResetConfirmRequest{Token: "bogus", Password: "long-enough-password"})
+		expectStatus(t, rec, http.StatusBadRequest)
+	})
+
+	// "Недійсне посилання" — лише про сам токен; інші помилки скидання мають власний статус.
+	t.Run("UnknownUser", func(t *testing.T) {
+		orphan, err := auth.IssueActionToken(auth.ActionToken{Purpose: auth.PurposePasswordReset, UserID: 1 << 30}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := call(t, http.MethodPost, "/api/sso/password_reset", "", domain.PasswordResetConfirmRequest{Token: orphan, Password: "long-enough-password"})
+		expectStatus(t, rec, http.StatusNotFound)
+	})
+
+	// Новий пароль перевіряється тими самими правилами, що й при реєстрації.
+	for name, password := range map[string]string{"EmptyPassword": "", "ShortPassword": "x", "LongPassword": strings.Repeat("я", 40)} {
+		t.Run(name, func(t *testing.T) {
+			rec := call(t, http.MethodPost, "/api/sso/password_reset", "", domain.PasswordResetConfirmRequest{Token: token, Password: password})
+			expectStatus(t, rec, http.StatusBadRequest)
+
+			resp := decode[responseHTTP.Problem](t, rec)
+			if resp.Code != responseHTTP.CodeValidation || len(resp.Errors) != 1 || resp.Errors[0].Field != "password" {
+				t.Errorf("error response = %+v, want validation error on password", resp)
+			}
+		})
+	}
+
+	rec = call(t, http.MethodPost, "/api/sso/password_reset", "", domain.PasswordResetConfirmRequest{Token: token, Password: "brand-new-password"})
+	expectStatus(t, rec, http.StatusOK)
+
+	// Скидання завершує всі сесії, а токен одноразовий.
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", oldToken, nil), http.StatusUnauthorized)
+	rec = call(t, http.MethodPost, "/api/sso/password_reset", "", domain.PasswordResetConfirmRequest{Token: token, Password: "another-password"})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	u.Password = "brand-new-password"
+	login(t, u)
+}
+
+func TestNotMe(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+	claims, _ := auth.ParseToken(token)
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodGet, "/api/sso/security/not_me?token=bogus", "", nil), http.StatusBadRequest)
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/security/not_me", "", url.Values{"token": {"bogus"}}), http.StatusBadRequest)
+	})
+
+	report, err := auth.IssueActionToken(auth.ActionToken{
+		Purpose:   auth.PurposeReportLogin,
+		UserID:    u.ID,
+		SessionID: claims.SessionID,
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Відкриття посилання (як це робить поштовий сканер) лише показує форму підтвердження.
+	rec := call(t, http.MethodGet, "/api/sso/security/not_me?token="+report, "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want an HTML page", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `method="post"`) || !strings.Contains(rec.Body.String(), report) {
+		t.Errorf("confirmation page lacks the form: %s", rec.Body.String())
+	}
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", token, nil), http.StatusOK)
+
+	expectStatus(t, call(t, http.MethodPost, "/api/sso/security/not_me", "", url.Values{"token": {report}}), http.StatusOK)
+
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", token, nil), http.StatusUnauthorized)
+	user, _ := env.users.GetByID(context.Background(), u.ID)
+	if !user.PasswordResetRequired {
+		t.Error("password reset not required after report")
+	}
+	if len(env.audit.eventsOf(domain.AuditLoginReported, u.ID)) != 1 {
+		t.Error("login reported audit event not recorded")
+	}
+	if _, ok := env.mail.last(u.Email); !ok {
+		t.Error("password reset mail not sent")
+	}
+
+	t.Run("SingleUse", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/security/not_me", "", url.Values{"token": {report}}), http.StatusBadRequest)
+		if len(env.audit.eventsOf(domain.AuditLoginReported, u.ID)) != 1 {
+			t.Error("reused report token recorded another event")
+		}
+	})
+}
+
+type memoryDevices struct {
+	mu      sync.Mutex
+	devices []domain.KnownDevice
+}
+
+func (r *memoryDevices) CountDevices(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, device := range r.devices {
+		if device.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryDevices) IsKnownDevice(ctx context.Context, userID int, fingerprint string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.devices, func(d domain.KnownDevice) bool {
+		return d.UserID == userID && d.Fingerprint == fingerprint
+	}), nil
+}
+
+func (r *memoryDevices) IsKnownCountry(ctx context.Context, userID int, country string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.devices, func(d domain.KnownDevice) bool {
+		return d.UserID == userID && d.Country == country
+	}), nil
+}
+
+func (r *memoryDevices) RememberDevice(ctx context.Context, device domain.KnownDevice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, d := range r.devices {
+		if d.UserID == device.UserID && d.Fingerprint == device.Fingerprint {
+			r.devices[i].LastSeenAt = device.LastSeenAt
+			return nil
+		}
+	}
+	r.devices = append(r.devices, device)
+	return nil
+}
+
+type memoryUsedTokens struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func (r *memoryUsedTokens) MarkUsed(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.used[tokenID]; ok {
+		return false, nil
+	}
+	r.used[tokenID] = expiresAt
+	return true, nil
+}
+
+// captureMailer зберігає надіслані листи, щоб тести могли дістати з них токени.
+type captureMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *captureMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// last повертає останній лист на адресу to.
+func (m *captureMailer) last(to string) (mailer.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return mailer.Message{}, false
+}
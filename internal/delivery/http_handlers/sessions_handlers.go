@@ -0,0 +1,66 @@
+package http_handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/internal/service"
+	"sso-service/pkg/auth"
+
+	"github.com/gorilla/mux"
+)
+
+type SessionsHandler struct {
+	sessions *service.SessionsService
+	audit    *service.AuditService
+}
+
+func NewSessionsHandler(sessions *service.SessionsService, audit *service.AuditService) *SessionsHandler {
+	return &SessionsHandler{
+		sessions: sessions,
+		audit:    audit,
+	}
+}
+
+func (h *SessionsHandler) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	sessionID := auth.SessionIDFrom(r.Context())
+
+	sessions, err := h.sessions.List(r.Context(), userID, sessionID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні сесій", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, sessions)
+}
+
+func (h *SessionsHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	sessionID := mux.Vars(r)["id"]
+
+	if err := h.sessions.Revoke(r.Context(), userID, sessionID); err != nil {
+		slog.DebugContext(r.Context(), "Помилка при відкликанні сесії", "session_id", sessionID, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.SessionNotFound)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditTokenRevoked, userID, userID, map[string]any{"session_id": sessionID})
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.LoggedOut)
+}
@@ -0,0 +1,121 @@
+package http_handlers_test
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	current := login(t, u)
+	other := login(t, u)
+	otherClaims, _ := auth.ParseToken(other)
+
+	rec := call(t, http.MethodGet, "/api/sso/sessions", current, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	sessions := decode[[]domain.Session](t, rec)
+	if len(sessions) != 2 {
+		t.Fatalf("listed %d sessions, want 2", len(sessions))
+	}
+
+	t.Run("ForeignSession", func(t *testing.T) {
+		stranger := login(t, newUser(t, "user"))
+		rec := call(t, http.MethodDelete, "/api/sso/sessions/"+otherClaims.SessionID, stranger, nil)
+		expectStatus(t, rec, http.StatusNotFound)
+	})
+
+	rec = call(t, http.MethodDelete, "/api/sso/sessions/"+otherClaims.SessionID, current, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", other, nil), http.StatusUnauthorized)
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", current, nil), http.StatusOK)
+}
+
+type memorySessions struct {
+	mu       sync.Mutex
+	sessions map[string]domain.Session
+}
+
+func (r *memorySessions) CreateSession(ctx context.Context, session domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *memorySessions) GetSession(ctx context.Context, sessionID string) (domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return session, domain.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (r *memorySessions) ListActiveSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []domain.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *memorySessions) TouchSession(ctx context.Context, sessionID string, ip, userAgent string, seenAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	session.LastSeenAt = seenAt
+	if ip != "" {
+		session.IP = ip
+	}
+	if userAgent != "" {
+		session.UserAgent = userAgent
+	}
+	r.sessions[sessionID] = session
+	return nil
+}
+
+func (r *memorySessions) RevokeSession(ctx context.Context, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok || session.RevokedAt != nil {
+		return domain.ErrSessionNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	r.sessions[sessionID] = session
+	return nil
+}
+
+func (r *memorySessions) RevokeUserSessions(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			r.sessions[id] = session
+		}
+	}
+	return nil
+}
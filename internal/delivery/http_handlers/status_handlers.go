@@ -0,0 +1,263 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func writeStatusError(w http.ResponseWriter, r *http.Request, err error) {
+	var statusErr *domain.UserStatusError
+	if !errors.As(err, &statusErr) {
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	switch statusErr.Status {
+	case domain.UserStatusPendingVerification:
+		responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.AccountNotVerified)
+	case domain.UserStatusSuspended:
+		responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.AccountSuspended)
+	case domain.UserStatusDeactivated:
+		responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.AccountDeactivated)
+	default:
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.UserNotFound)
+	}
+}
+
+func (h *UsersHandler) revokeAllSessions(r *http.Request, actorID, userID int, reason string) {
+	if err := h.sessions.RevokeAll(r.Context(), userID); err != nil {
+		slog.ErrorContext(r.Context(), "Не вдалося відкликати сесії користувача", "user_id", userID, "err", err.Error())
+		return
+	}
+	recordAudit(h.audit, r, domain.AuditTokenRevoked, actorID, userID, map[string]any{"all_sessions": true, "reason": reason})
+}
+
+// RefreshHandler видає новий токен сесії. Делегований токен не можна обміняти на повний токен користувача.
+func (h *UsersHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetByID(r.Context(), userID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні користувача", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	if err := h.service.EnsureActive(r.Context(), &user); err != nil {
+		slog.DebugContext(r.Context(), "Оновлення токена заборонено статусом користувача", "user_id", userID, "err", err.Error())
+		writeStatusError(w, r, err)
+		return
+	}
+
+	// Токени, видані до появи сесій, не мають sid — для них стартуємо нову сесію.
+	sessionID := auth.SessionIDFrom(r.Context())
+	if sessionID == "" {
+		session, err := h.sessions.Start(r.Context(), user.UserID, "", clientIP(r), r.UserAgent())
+		if err != nil {
+			slog.DebugContext(r.Context(), "Помилка при створенні сесії", "err", err.Error())
+			responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+			return
+		}
+		sessionID = session.ID
+	} else if err := h.sessions.Refresh(r.Context(), sessionID, user.UserID, clientIP(r), r.UserAgent()); err != nil {
+		slog.DebugContext(r.Context(), "Помилка при оновленні сесії", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	// Оновлення токена не є повторною автентифікацією: auth_time та amr лишаються від входу.
+	claims := auth.JWTToken{Username: user.Login, UserID: user.UserID, SessionID: sessionID, Roles: user.Roles()}
+	if current, ok := auth.TokenFromContext(r.Context()); ok {
+		claims.CopyAuthContext(current)
+	}
+
+	token, err := auth.IssueToken(claims, h.tokenTTL)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.TokenResponse{Token: token})
+}
+
+// ReauthenticateHandler підтверджує особу паролем і видає токен тієї ж сесії з новим auth_time,
+// після чого стають доступні дії під auth.RequireStepUp.
+func (h *UsersHandler) ReauthenticateHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := interactiveUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.ReauthenticateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	if err := h.service.VerifyPassword(r.Context(), userID, req.Password); err != nil {
+		slog.DebugContext(r.Context(), "Повторна автентифікація не вдалася", "user_id", userID, "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, userID, userID, map[string]any{"reauthenticate": true})
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.InvalidPassword)
+		return
+	}
+
+	user, err := h.service.GetByID(r.Context(), userID)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при отриманні користувача", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	sessionID := auth.SessionIDFrom(r.Context())
+	claims := auth.JWTToken{Username: user.Login, UserID: user.UserID, SessionID: sessionID, Roles: user.Roles()}
+	claims.SetAuthContext(time.Now(), []string{auth.AMRPassword})
+
+	token, err := auth.IssueToken(claims, h.tokenTTL)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditReauthenticated, userID, userID, map[string]any{"session_id": sessionID, "amr": claims.AMR})
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.TokenResponse{Token: token})
+}
+
+func (h *UsersHandler) DeactivateHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	var req domain.DeactivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	if err := h.service.Deactivate(r.Context(), userID, req.Password); err != nil {
+		slog.DebugContext(r.Context(), "Помилка деактивації облікового запису", "user_id", userID, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.InvalidPassword)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditAccountDeactivated, userID, userID, nil)
+	h.revokeAllSessions(r, userID, userID, "deactivated")
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.AccountDeactivated)
+}
+
+func (h *UsersHandler) ReactivateHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		responseHTTP.Error(w, r, err, i18n.CheckFields)
+		return
+	}
+
+	user, err := h.service.Reactivate(r.Context(), req.Email, req.Password)
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка реактивації облікового запису", "err", err.Error())
+		var statusErr *domain.UserStatusError
+		if errors.As(err, &statusErr) {
+			writeStatusError(w, r, err)
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrUnauthorized) {
+			responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.InvalidCredentials)
+			return
+		}
+		responseHTTP.Error(w, r, err, "")
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditAccountReactivated, user.UserID, user.UserID, nil)
+
+	token, _, err := issueSessionToken(r, h.sessions, h.tokenTTL, user, req.DeviceName, []string{auth.AMRPassword})
+	if err != nil {
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	responseHTTP.JSONResp(w, http.StatusOK, domain.TokenResponse{Token: token})
+}
+
+func (h *UsersHandler) SuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidUserID)
+		return
+	}
+
+	var req domain.SuspendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	adminID, ok := auth.UserIDFrom(r.Context())
+	if !ok {
+		slog.DebugContext(r.Context(), "Помилка при отриманні user_id з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
+		return
+	}
+
+	if err := h.service.Suspend(r.Context(), adminID, targetID, req.Reason, req.Until); err != nil {
+		slog.DebugContext(r.Context(), "Помилка блокування користувача", "user_id", targetID, "err", err.Error())
+		message := i18n.SuspendFailed
+		if errors.Is(err, domain.ErrForbidden) {
+			message = i18n.CannotSuspend
+		}
+		responseHTTP.Error(w, r, err, message)
+		return
+	}
+
+	details := map[string]any{"reason": req.Reason}
+	if req.Until != nil {
+		details["until"] = req.Until.Format(time.RFC3339)
+	}
+	recordAudit(h.audit, r, domain.AuditAdminUserSuspended, adminID, targetID, details)
+	h.revokeAllSessions(r, adminID, targetID, "suspended")
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.UserSuspended)
+}
+
+func (h *UsersHandler) UnsuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidUserID)
+		return
+	}
+
+	if err := h.service.Unsuspend(r.Context(), targetID); err != nil {
+		slog.DebugContext(r.Context(), "Помилка розблокування користувача", "user_id", targetID, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.UnsuspendFailed)
+		return
+	}
+
+	adminID, _ := auth.UserIDFrom(r.Context())
+	recordAudit(h.audit, r, domain.AuditAdminUserUnsuspended, adminID, targetID, nil)
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.UserUnsuspended)
+}
@@ -0,0 +1,139 @@
+package http_handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+	before, _ := auth.ParseToken(token)
+
+	rec := call(t, http.MethodPost, "/api/sso/refresh", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	after, err := auth.ParseToken(decode[domain.TokenResponse](t, rec).Token)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if after.SessionID != before.SessionID {
+		t.Errorf("refreshed session = %q, want %q", after.SessionID, before.SessionID)
+	}
+	if after.AuthTime != before.AuthTime {
+		t.Errorf("refresh changed auth_time from %d to %d", before.AuthTime, after.AuthTime)
+	}
+
+	// Оновлення не продовжує сесію без кінця: після її абсолютного строку потрібен новий вхід.
+	t.Run("SessionMaxAge", func(t *testing.T) {
+		env.sessions.mu.Lock()
+		session := env.sessions.sessions[before.SessionID]
+		session.CreatedAt = time.Now().Add(-sessionMaxAge - time.Minute)
+		env.sessions.sessions[before.SessionID] = session
+		env.sessions.mu.Unlock()
+
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/refresh", token, nil), http.StatusUnauthorized)
+	})
+}
+
+func TestReauthenticate(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := staleToken(t, u)
+	before, _ := auth.ParseToken(token)
+
+	rec := call(t, http.MethodPost, "/api/sso/reauthenticate", token, domain.ReauthenticateRequest{Password: "wrong"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = call(t, http.MethodPost, "/api/sso/reauthenticate", token, domain.ReauthenticateRequest{Password: u.Password})
+	expectStatus(t, rec, http.StatusOK)
+
+	after, err := auth.ParseToken(decode[domain.TokenResponse](t, rec).Token)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if after.SessionID != before.SessionID || after.AuthTime <= before.AuthTime {
+		t.Errorf("reauthenticated token sid=%q auth_time=%d, want same session and fresh auth_time", after.SessionID, after.AuthTime)
+	}
+}
+
+func TestDeactivateAndReactivate(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	rec := call(t, http.MethodPost, "/api/sso/user_profile/deactivate", token, domain.DeactivateRequest{Password: "wrong"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = call(t, http.MethodPost, "/api/sso/user_profile/deactivate", token, domain.DeactivateRequest{Password: u.Password})
+	expectStatus(t, rec, http.StatusOK)
+
+	// Сесії відкликано, а вхід заборонено до реактивації.
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", token, nil), http.StatusUnauthorized)
+	rec = call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{Email: u.Email, Password: u.Password})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = call(t, http.MethodPost, "/api/sso/reactivate", "", domain.LoginRequest{Email: u.Email, Password: "wrong"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = call(t, http.MethodPost, "/api/sso/reactivate", "", domain.LoginRequest{Email: u.Email, Password: u.Password})
+	expectStatus(t, rec, http.StatusOK)
+	if decode[domain.TokenResponse](t, rec).Token == "" {
+		t.Error("reactivation returned no token")
+	}
+	login(t, u)
+}
+
+func TestSuspendAndUnsuspend(t *testing.T) {
+	t.Parallel()
+
+	admin := newUser(t, "admin")
+	adminToken := login(t, admin)
+	u := newUser(t, "user")
+	userToken := login(t, u)
+
+	suspend := fmt.Sprintf("/api/sso/admin/users/%d/suspend", u.ID)
+	unsuspend := fmt.Sprintf("/api/sso/admin/users/%d/unsuspend", u.ID)
+
+	t.Run("RequiresAdmin", func(t *testing.T) {
+		rec := call(t, http.MethodPost, suspend, userToken, domain.SuspendRequest{Reason: "spam"})
+		expectStatus(t, rec, http.StatusForbidden)
+	})
+
+	t.Run("NotSelfOrAdmin", func(t *testing.T) {
+		for _, target := range []int{admin.ID, newUser(t, "admin").ID} {
+			path := fmt.Sprintf("/api/sso/admin/users/%d/suspend", target)
+			expectStatus(t, call(t, http.MethodPost, path, adminToken, domain.SuspendRequest{Reason: "spam"}), http.StatusForbidden)
+		}
+		login(t, admin)
+	})
+
+	until := time.Now().Add(time.Hour)
+	rec := call(t, http.MethodPost, suspend, adminToken, domain.SuspendRequest{Reason: "spam", Until: &until})
+	expectStatus(t, rec, http.StatusOK)
+
+	user, _ := env.users.GetByID(context.Background(), u.ID)
+	if user.Status != domain.UserStatusSuspended {
+		t.Fatalf("status = %q, want suspended", user.Status)
+	}
+	if len(env.audit.eventsOf(domain.AuditAdminUserSuspended, u.ID)) != 1 {
+		t.Error("suspend audit event not recorded")
+	}
+
+	expectStatus(t, call(t, http.MethodGet, "/api/sso/user_profile", userToken, nil), http.StatusUnauthorized)
+	rec = call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{Email: u.Email, Password: u.Password})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	expectStatus(t, call(t, http.MethodPost, unsuspend, adminToken, nil), http.StatusOK)
+	login(t, u)
+}
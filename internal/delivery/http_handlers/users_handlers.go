@@ -2,9 +2,11 @@ package http_handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
 	"sso-service/internal/lib/responseHTTP"
 	"sso-service/internal/service"
 	"sso-service/pkg/auth"
@@ -13,13 +15,62 @@ import (
 
 type UsersHandler struct {
 	service  *service.UsersService
+	sessions *service.SessionsService
+	devices  *service.DevicesService
+	audit    *service.AuditService
+	cookies  *CookieSessions
 	tokenTTL time.Duration
+
+	impersonationTTL time.Duration
 }
 
-func NewUsersHandler(service *service.UsersService, tokenTTL time.Duration) *UsersHandler {
+func NewUsersHandler(service *service.UsersService, sessions *service.SessionsService, devices *service.DevicesService,
+	audit *service.AuditService, cookies *CookieSessions, tokenTTL, impersonationTTL time.Duration) *UsersHandler {
 	return &UsersHandler{
-		service:  service,
-		tokenTTL: tokenTTL,
+		service:          service,
+		sessions:         sessions,
+		devices:          devices,
+		audit:            audit,
+		cookies:          cookies,
+		tokenTTL:         tokenTTL,
+		impersonationTTL: impersonationTTL,
+	}
+}
+
+// issueSessionToken стартує нову сесію і видає прив'язаний до неї токен.
+// amr — методи, якими користувач щойно підтвердив особу.
+func issueSessionToken(r *http.Request, sessions *service.SessionsService, tokenTTL time.Duration, user domain.User, deviceName string,
+	amr []string) (string, string, error) {
+	session, err := sessions.Start(r.Context(), user.UserID, deviceName, clientIP(r), r.UserAgent())
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := auth.JWTToken{
+		Username:  user.Login,
+		UserID:    user.UserID,
+		SessionID: session.ID,
+		Roles:     user.Roles(),
+	}
+	claims.SetAuthContext(time.Now(), amr)
+
+	token, err := auth.IssueToken(claims, tokenTTL)
+
+	return token, session.ID, err
+}
+
+// userErrorMessage називає зайняте поле, якщо помилка — конфлікт email чи логіна,
+// або просить перевірити поля, якщо дані не пройшли валідацію в сервісі.
+func userErrorMessage(err error) i18n.Key {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return i18n.CheckFields
+	case errors.Is(err, domain.ErrEmailTaken):
+		return i18n.EmailTaken
+	case errors.Is(err, domain.ErrLoginTaken):
+		return i18n.LoginTaken
+	default:
+		return ""
 	}
 }
 
@@ -28,31 +79,23 @@ func (h *UsersHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&regRequest)
 	if err != nil {
-		responseHTTP.JSONError(w, http.StatusBadRequest, "Неправильний запит")
-		return
-	}
-
-	exists, err := h.service.ExistsByEmail(r.Context(), regRequest.Email)
-	if err != nil {
-		slog.Debug("Помилка при перевірці email", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
-		return
-	}
-	if exists {
-		responseHTTP.JSONError(w, http.StatusConflict, "Користувач з таким email вже існує")
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
 		return
 	}
 
 	if err := h.service.CreateUser(r.Context(), &regRequest); err != nil {
-		slog.Debug("Помилка при створені користувача", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
+		slog.DebugContext(r.Context(), "Помилка при створені користувача", "err", err.Error())
+		responseHTTP.Error(w, r, err, userErrorMessage(err))
 		return
 	}
 
-	token, err := auth.CreateToken(regRequest.Login, regRequest.UserID, h.tokenTTL)
+	recordAudit(h.audit, r, domain.AuditRegistration, regRequest.UserID, regRequest.UserID, nil)
+
+	token, _, err := issueSessionToken(r, h.sessions, h.tokenTTL, domain.User{UserID: regRequest.UserID, Login: regRequest.Login, Role: regRequest.Role},
+		"", []string{auth.AMRPassword})
 	if err != nil {
-		slog.Debug("Помилка при створені токена", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
 		return
 	}
 
@@ -68,28 +111,80 @@ func (h *UsersHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&loginReq)
 	if err != nil {
-		slog.Debug("Помилка при декодуванні loginReq", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusBadRequest, "Неправильний запит")
+		slog.DebugContext(r.Context(), "Помилка при декодуванні loginReq", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.BadRequest)
+		return
+	}
+
+	if err := loginReq.Validate(); err != nil {
+		responseHTTP.Error(w, r, err, i18n.CheckFields)
+		return
+	}
+
+	cookieMode := loginReq.SessionMode == domain.SessionModeCookie
+	if cookieMode && !h.cookies.Enabled() {
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.CookieSessionsDisabled)
 		return
 	}
 
 	user, err := h.service.GetByEmail(r.Context(), loginReq.Email)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		responseHTTP.Error(w, r, err, "")
+		return
+	}
 	if err != nil {
-		slog.Debug("Користувача не знайдено", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusUnauthorized, "Користувача не знайдено")
+		slog.DebugContext(r.Context(), "Користувача не знайдено", "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, 0, map[string]any{"email": loginReq.Email, "reason": "unknown_email"})
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.UserNotFound)
 		return
 	}
 
 	if err := auth.CheckPassword(user.HashPassword, loginReq.Password); err != nil {
-		slog.Debug("Неправильний пароль", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusUnauthorized, "Неправильний пароль")
+		slog.DebugContext(r.Context(), "Неправильний пароль", "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, user.UserID, map[string]any{"reason": "invalid_password"})
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.InvalidPassword)
 		return
 	}
 
-	token, err := auth.CreateToken(user.Login, user.UserID, h.tokenTTL)
+	if err := h.service.EnsureActive(r.Context(), &user); err != nil {
+		slog.DebugContext(r.Context(), "Вхід заборонено статусом користувача", "user_id", user.UserID, "err", err.Error())
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, user.UserID, map[string]any{"reason": "status", "status": user.EffectiveStatus(time.Now())})
+		writeStatusError(w, r, err)
+		return
+	}
+
+	if user.PasswordResetRequired {
+		slog.DebugContext(r.Context(), "Вхід заборонено до скидання пароля", "user_id", user.UserID)
+		recordAudit(h.audit, r, domain.AuditLoginFailure, 0, user.UserID, map[string]any{"reason": "password_reset_required"})
+		responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.PasswordResetRequired)
+		return
+	}
+
+	token, sessionID, err := issueSessionToken(r, h.sessions, h.tokenTTL, user, loginReq.DeviceName, []string{auth.AMRPassword})
 	if err != nil {
-		slog.Debug("Помилка при створені токена", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
+		slog.DebugContext(r.Context(), "Помилка при створені токена", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+		return
+	}
+
+	recordAudit(h.audit, r, domain.AuditLoginSuccess, user.UserID, user.UserID, map[string]any{"session_id": sessionID})
+
+	newDevice, err := h.devices.CheckLogin(r.Context(), user, sessionID, clientIP(r), r.UserAgent(), clientCountry(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Помилка перевірки пристрою", "user_id", user.UserID, "err", err.Error())
+	}
+	if newDevice {
+		recordAudit(h.audit, r, domain.AuditNewDeviceLogin, user.UserID, user.UserID, map[string]any{"session_id": sessionID, "country": clientCountry(r)})
+	}
+
+	if cookieMode {
+		csrfToken, err := h.cookies.Start(w, token)
+		if err != nil {
+			slog.DebugContext(r.Context(), "Помилка при створенні cookie-сесії", "err", err.Error())
+			responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
+			return
+		}
+		responseHTTP.JSONResp(w, http.StatusOK, domain.CookieSessionResponse{CSRFToken: csrfToken})
 		return
 	}
 
@@ -101,19 +196,19 @@ func (h *UsersHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UsersHandler) UserProfileHandler(w http.ResponseWriter, r *http.Request) {
-	username, ok := r.Context().Value("username").(string)
+	principal, ok := auth.PrincipalFrom(r.Context())
 	if !ok {
-		slog.Debug("Помилка при отриманні username з context")
-		responseHTTP.JSONError(w, http.StatusUnauthorized, "Не авторизовано")
+		slog.DebugContext(r.Context(), "Помилка при отриманні principal з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
 		return
 	}
 
-	slog.Debug("Запит на отримання профілю")
+	slog.DebugContext(r.Context(), "Запит на отримання профілю")
 
-	user, err := h.service.GetByUsername(r.Context(), username)
+	user, err := h.service.GetByID(r.Context(), principal.UserID)
 	if err != nil {
-		slog.Debug("Помилка при отриманні користувача", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
+		slog.DebugContext(r.Context(), "Помилка при отриманні користувача", "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.UserNotFound)
 		return
 	}
 
@@ -121,17 +216,18 @@ func (h *UsersHandler) UserProfileHandler(w http.ResponseWriter, r *http.Request
 }
 
 func (h *UsersHandler) UpdateUserProfileHandler(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(int)
+	principal, ok := auth.PrincipalFrom(r.Context())
 	if !ok {
-		slog.Debug("Помилка при отриманні user_id з context")
-		responseHTTP.JSONError(w, http.StatusUnauthorized, "Не авторизовано")
+		slog.DebugContext(r.Context(), "Помилка при отриманні principal з context")
+		responseHTTP.JSONError(w, r, http.StatusUnauthorized, i18n.Unauthorized)
 		return
 	}
+	userID := principal.UserID
 
 	err := r.ParseMultipartForm(10 << 20)
 	if err != nil {
-		slog.Debug("Помилка парсингу форми", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusBadRequest, "Помилка парсингу форми")
+		slog.DebugContext(r.Context(), "Помилка парсингу форми", "err", err.Error())
+		responseHTTP.JSONError(w, r, http.StatusBadRequest, i18n.InvalidForm)
 		return
 	}
 
@@ -146,10 +242,16 @@ func (h *UsersHandler) UpdateUserProfileHandler(w http.ResponseWriter, r *http.R
 		Address:     r.FormValue("Address"),
 	}
 
-	if userData.Login == "" || userData.Password == "" || userData.FirstName == "" ||
-		userData.LastName == "" || userData.Email == "" || userData.Phonenumber == "" || userData.Address == "" {
-		slog.Debug("Не всі поля заповнені", "userData", userData)
-		responseHTTP.JSONError(w, http.StatusBadRequest, "Усі поля повинні бути заповнені")
+	if err := userData.Validate(); err != nil {
+		slog.DebugContext(r.Context(), "Неправильні дані профілю", "user_id", userID, "err", err.Error())
+		responseHTTP.Error(w, r, err, i18n.CheckFields)
+		return
+	}
+
+	// Під час імперсонації профіль редагувати можна, а пароль — ні.
+	if _, impersonating := auth.ImpersonatorFromContext(r.Context()); impersonating && userData.Password != "" {
+		slog.DebugContext(r.Context(), "Спроба змінити пароль під час імперсонації", "user_id", userID)
+		responseHTTP.JSONError(w, r, http.StatusForbidden, i18n.PasswordChangeImpersonation)
 		return
 	}
 
@@ -158,19 +260,31 @@ func (h *UsersHandler) UpdateUserProfileHandler(w http.ResponseWriter, r *http.R
 		defer file.Close()
 		avatarPath, saveErr := h.service.SaveAvatar(header)
 		if saveErr != nil {
-			slog.Debug("Помилка збереження аватара", "err", saveErr.Error())
-			responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
+			slog.DebugContext(r.Context(), "Помилка збереження аватара", "err", saveErr.Error())
+			responseHTTP.JSONError(w, r, http.StatusInternalServerError, i18n.InternalError)
 			return
 		}
 		userData.AvatarPath = avatarPath
 	}
 
-	err = h.service.UpdateUserProfile(r.Context(), userData)
+	changed, err := h.service.UpdateUserProfile(r.Context(), userData)
 	if err != nil {
-		slog.Debug("Помилка оновлення профілю", "err", err.Error())
-		responseHTTP.JSONError(w, http.StatusInternalServerError, "Помилка на сервері")
+		slog.DebugContext(r.Context(), "Помилка оновлення профілю", "err", err.Error())
+		responseHTTP.Error(w, r, err, userErrorMessage(err))
 		return
 	}
 
-	responseHTTP.JSONResp(w, http.StatusOK, "Профіль оновлено")
+	var profileFields []string
+	for _, field := range changed {
+		if field == "Password" {
+			recordAudit(h.audit, r, domain.AuditPasswordChange, userID, userID, nil)
+			continue
+		}
+		profileFields = append(profileFields, field)
+	}
+	if len(profileFields) > 0 {
+		recordAudit(h.audit, r, domain.AuditProfileUpdate, userID, userID, map[string]any{"fields": profileFields})
+	}
+
+	responseHTTP.JSONRespMessage(w, r, http.StatusOK, i18n.ProfileUpdated)
 }
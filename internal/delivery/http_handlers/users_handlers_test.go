@@ -0,0 +1,296 @@
+package http_handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
+	"sso-service/pkg/auth"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	req := domain.RegisterRequest{
+		Login:       "register-ok",
+		Email:       "register-ok@carvia.test",
+		Password:    "secret-password",
+		FirstName:   "Оксана",
+		LastName:    "Бондар",
+		Phonenumber: "+380931234567",
+		Address:     "Харків",
+	}
+
+	rec := call(t, http.MethodPost, "/api/sso/register", "", req)
+	expectStatus(t, rec, http.StatusOK)
+
+	claims, err := auth.ParseToken(decode[domain.TokenResponse](t, rec).Token)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if claims.Username != req.Login || claims.SessionID == "" {
+		t.Errorf("token claims = %+v, want login %q and a session", claims, req.Login)
+	}
+
+	user, err := env.users.GetByEmail(context.Background(), req.Email)
+	if err != nil {
+		t.Fatalf("registered user not stored: %v", err)
+	}
+	if user.Role != "user" || user.HashPassword == req.Password {
+		t.Errorf("stored user role=%q, password must be hashed", user.Role)
+	}
+	if len(env.audit.eventsOf(domain.AuditRegistration, user.UserID)) != 1 {
+		t.Error("registration audit event not recorded")
+	}
+
+	conflictField := func(t *testing.T, rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		expectStatus(t, rec, http.StatusConflict)
+		resp := decode[responseHTTP.Problem](t, rec)
+		if len(resp.Errors) != 1 {
+			t.Fatalf("conflict fields = %+v, want one", resp.Errors)
+		}
+		return resp.Errors[0].Field
+	}
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		dup := req
+		dup.Login = "register-dup"
+		dup.Email = "  Register-OK@Carvia.TEST"
+		if field := conflictField(t, call(t, http.MethodPost, "/api/sso/register", "", dup)); field != "Email" {
+			t.Errorf("conflict field = %q, want Email", field)
+		}
+	})
+
+	t.Run("DuplicateLogin", func(t *testing.T) {
+		dup := req
+		dup.Login = "Register-OK"
+		dup.Email = "register-dup@carvia.test"
+		if field := conflictField(t, call(t, http.MethodPost, "/api/sso/register", "", dup)); field != "Login" {
+			t.Errorf("conflict field = %q, want Login", field)
+		}
+	})
+
+	t.Run("NormalizedEmail", func(t *testing.T) {
+		// Повноширинні символи NFKC зводить до ASCII, регістр і пробіли ігноруються.
+		rec := call(t, http.MethodPost, "/api/sso/login", "",
+			domain.LoginRequest{Email: " ＲＥＧＩＳＴＥＲ-ok@Carvia.test ", Password: req.Password})
+		expectStatus(t, rec, http.StatusOK)
+	})
+
+	t.Run("MalformedBody", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/register", "", "{"), http.StatusBadRequest)
+	})
+
+	t.Run("InvalidFields", func(t *testing.T) {
+		bad := req
+		bad.Login = "ab"
+		bad.Email = "not-an-email"
+		bad.Password = ""
+		bad.Phonenumber = "call me"
+
+		rec := call(t, http.MethodPost, "/api/sso/register", "", bad)
+		expectStatus(t, rec, http.StatusBadRequest)
+
+		resp := decode[responseHTTP.Problem](t, rec)
+		got := map[string]string{}
+		for _, f := range resp.Errors {
+			got[f.Field] = f.Code
+		}
+		want := map[string]string{
+			"Login":       domain.RuleTooShort,
+			"Email":       domain.RuleInvalidEmail,
+			"Password":    domain.RuleRequired,
+			"Phonenumber": domain.RuleInvalidPhone,
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("field errors = %v, want %v", got, want)
+		}
+	})
+
+	// CLI викликає сервіс напряму, тож правила мають застосовуватися і там.
+	t.Run("ServiceValidates", func(t *testing.T) {
+		bad := domain.RegisterRequest{Login: "cli-admin", Email: "cli-admin@carvia.test", Password: "short", FirstName: "Тарас", LastName: "Мельник"}
+		if err := env.usersService.CreateAdmin(context.Background(), &bad); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("CreateAdmin with a short password = %v, want validation error", err)
+		}
+
+		u := newUser(t, "user")
+		if err := env.usersService.SetPassword(context.Background(), u.ID, "short"); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("SetPassword with a short password = %v, want validation error", err)
+		}
+		update := domain.UserUpdateRequest{
+			UserID: u.ID, Login: u.Login, Email: u.Email, Password: "short",
+			FirstName: "Тарас", LastName: "Мельник", Phonenumber: "+380501112233", Address: "Одеса",
+		}
+		if _, err := env.usersService.UpdateUserProfile(context.Background(), update); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("UpdateUserProfile with a short password = %v, want validation error", err)
+		}
+		if _, err := env.usersService.ResetPassword(context.Background(), "bogus", "short"); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("ResetPassword with a short password = %v, want validation error", err)
+		}
+		login(t, u)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+
+	t.Run("Success", func(t *testing.T) {
+		claims, err := auth.ParseToken(login(t, u))
+		if err != nil {
+			t.Fatalf("parse token: %v", err)
+		}
+		if claims.UserID != u.ID || claims.SessionID == "" {
+			t.Errorf("token claims = %+v, want user %d with a session", claims, u.ID)
+		}
+		if len(env.audit.eventsOf(domain.AuditLoginSuccess, u.ID)) == 0 {
+			t.Error("login success audit event not recorded")
+		}
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{Email: u.Email, Password: "wrong"})
+		expectStatus(t, rec, http.StatusUnauthorized)
+		if len(env.audit.eventsOf(domain.AuditLoginFailure, u.ID)) == 0 {
+			t.Error("login failure audit event not recorded")
+		}
+	})
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		rec := call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{Email: "ghost@carvia.test", Password: "x"})
+		expectStatus(t, rec, http.StatusUnauthorized)
+	})
+
+	t.Run("MalformedBody", func(t *testing.T) {
+		expectStatus(t, call(t, http.MethodPost, "/api/sso/login", "", "not json"), http.StatusBadRequest)
+	})
+
+	t.Run("PasswordResetRequired", func(t *testing.T) {
+		locked := newUser(t, "user")
+		if err := env.users.SetPasswordResetRequired(context.Background(), locked.ID, true); err != nil {
+			t.Fatal(err)
+		}
+		rec := call(t, http.MethodPost, "/api/sso/login", "", domain.LoginRequest{Email: locked.Email, Password: locked.Password})
+		expectStatus(t, rec, http.StatusForbidden)
+	})
+}
+
+func TestUserProfile(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	rec := call(t, http.MethodGet, "/api/sso/user_profile", token, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	profile := decode[domain.User](t, rec)
+	if profile.UserID != u.ID || profile.Email != u.Email || profile.Login != u.Login {
+		t.Errorf("profile = %+v, want user %+v", profile, u)
+	}
+}
+
+// profileForm будує multipart-форму оновлення профілю без аватара.
+func profileForm(t *testing.T, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, form.FormDataContentType()
+}
+
+// profileFields — обов'язкові поля профілю u без пароля.
+func profileFields(u testUser) map[string]string {
+	return map[string]string{
+		"Login":       u.Login,
+		"FirstName":   "Тарас",
+		"LastName":    "Мельник",
+		"Email":       u.Email,
+		"Phonenumber": "+380501112233",
+		"Address":     "Одеса",
+	}
+}
+
+func updateProfile(t *testing.T, token string, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, contentType := profileForm(t, fields)
+	req := newRequest(t, http.MethodPut, "/api/sso/update_user_profile", nil)
+	req.Body = io.NopCloser(body)
+	req.ContentLength = int64(body.Len())
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return serve(req)
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	t.Parallel()
+
+	u := newUser(t, "user")
+	token := login(t, u)
+
+	update := func(fields map[string]string) *httptest.ResponseRecorder {
+		return updateProfile(t, token, fields)
+	}
+
+	fields := profileFields(u)
+	fields["Password"] = u.Password
+	fields["FirstName"] = "Андрій"
+	rec := update(fields)
+	expectStatus(t, rec, http.StatusOK)
+	if resp := decode[responseHTTP.MessageResponse](t, rec); resp.Status != http.StatusOK || resp.Code != string(i18n.ProfileUpdated) {
+		t.Errorf("response = %+v, want profile_updated message", resp)
+	}
+
+	user, _ := env.users.GetByID(context.Background(), u.ID)
+	if user.FirstName != "Андрій" {
+		t.Errorf("FirstName = %q, want it updated", user.FirstName)
+	}
+	if len(env.audit.eventsOf(domain.AuditProfileUpdate, u.ID)) != 1 {
+		t.Error("profile update audit event not recorded")
+	}
+
+	t.Run("PasswordOptional", func(t *testing.T) {
+		expectStatus(t, update(profileFields(u)), http.StatusOK)
+		login(t, u)
+		if n := len(env.audit.eventsOf(domain.AuditPasswordChange, u.ID)); n != 0 {
+			t.Errorf("password change audit events = %d, want 0", n)
+		}
+	})
+
+	t.Run("MissingField", func(t *testing.T) {
+		partial := map[string]string{"Login": u.Login}
+		expectStatus(t, update(partial), http.StatusBadRequest)
+	})
+
+	t.Run("TakenEmail", func(t *testing.T) {
+		taken := maps.Clone(fields)
+		taken["Email"] = newUser(t, "user").Email
+
+		rec := update(taken)
+		expectStatus(t, rec, http.StatusConflict)
+		if resp := decode[responseHTTP.Problem](t, rec); resp.Code != responseHTTP.CodeConflict {
+			t.Errorf("error code = %q, want %q", resp.Code, responseHTTP.CodeConflict)
+		}
+	})
+}
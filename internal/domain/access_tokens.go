@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PersonalTokenPrefix відрізняє персональні токени від JWT у заголовку Authorization.
+const PersonalTokenPrefix = "cvpat_"
+
+// PersonalTokenScopes — дозволи, які можна надати персональному токену.
+var PersonalTokenScopes = []string{
+	ScopeProfile,
+	ScopeListingsRead,
+	ScopeListingsWrite,
+	ScopeInventoryRead,
+	ScopeInventoryWrite,
+}
+
+var ErrAccessTokenNotFound = NewError(ErrNotFound, "token not found")
+
+// PersonalAccessToken — токен для скриптів та інтеграцій (DMS дилерів).
+// Сам токен показується лише при створенні, у БД зберігається його хеш.
+type PersonalAccessToken struct {
+	ID         int        `json:"ID"`
+	UserID     int        `json:"-"`
+	OrgID      *int       `json:"OrgID,omitempty"`
+	Name       string     `json:"Name"`
+	Prefix     string     `json:"Prefix"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"Scopes"`
+	ExpiresAt  *time.Time `json:"ExpiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"LastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"CreatedAt"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+type PersonalAccessTokenRepository interface {
+	CreateToken(ctx context.Context, token PersonalAccessToken) (int, error)
+	GetTokenByHash(ctx context.Context, tokenHash string) (PersonalAccessToken, error)
+	ListTokens(ctx context.Context, userID int) ([]PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID int) error
+	TouchToken(ctx context.Context, tokenID int, usedAt time.Time) error
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type AuditEventType string
+
+const (
+	AuditLoginSuccess         AuditEventType = "login_success"
+	AuditLoginFailure         AuditEventType = "login_failure"
+	AuditRegistration         AuditEventType = "registration"
+	AuditPasswordChange       AuditEventType = "password_change"
+	AuditProfileUpdate        AuditEventType = "profile_update"
+	AuditTokenRevoked         AuditEventType = "token_revoked"
+	AuditAccountDeactivated   AuditEventType = "account_deactivated"
+	AuditAccountReactivated   AuditEventType = "account_reactivated"
+	AuditAdminUserSuspended   AuditEventType = "admin_user_suspended"
+	AuditAdminUserUnsuspended AuditEventType = "admin_user_unsuspended"
+	AuditNewDeviceLogin       AuditEventType = "new_device_login"
+	AuditLoginReported        AuditEventType = "suspicious_login_reported"
+	AuditPasswordReset        AuditEventType = "password_reset"
+	AuditIdentityLinked       AuditEventType = "identity_linked"
+	AuditIdentityUnlinked     AuditEventType = "identity_unlinked"
+	AuditDeviceAuthorized     AuditEventType = "device_authorized"
+	AuditDeviceDenied         AuditEventType = "device_denied"
+	AuditAccessTokenCreated   AuditEventType = "access_token_created"
+	AuditImpersonationStarted AuditEventType = "impersonation_started"
+	AuditConsentGranted       AuditEventType = "consent_granted"
+	AuditConsentDenied        AuditEventType = "consent_denied"
+	AuditConsentRevoked       AuditEventType = "consent_revoked"
+	AuditTokenExchanged       AuditEventType = "token_exchanged"
+	AuditReauthenticated      AuditEventType = "reauthenticated"
+)
+
+type AuditEvent struct {
+	ID        int64          `json:"ID"`
+	Type      AuditEventType `json:"Type"`
+	ActorID   *int           `json:"ActorID,omitempty"`
+	TargetID  *int           `json:"TargetID,omitempty"`
+	IP        string         `json:"IP"`
+	UserAgent string         `json:"UserAgent"`
+	Details   map[string]any `json:"Details,omitempty"`
+	CreatedAt time.Time      `json:"CreatedAt"`
+}
+
+// AuditFilter обмежує вибірку подій. UserID відбирає події, де користувач є актором або ціллю.
+type AuditFilter struct {
+	UserID   *int
+	ActorID  *int
+	TargetID *int
+	Types    []AuditEventType
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+// AuditRepository навмисно не має методів зміни або видалення — журнал лише доповнюється.
+type AuditRepository interface {
+	Append(ctx context.Context, event AuditEvent) error
+	List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var ErrConsentNotFound = NewError(ErrNotFound, "consent not found")
+
+// ConsentGrant — дозволи, які користувач надав сторонньому застосунку.
+type ConsentGrant struct {
+	UserID     int       `json:"-"`
+	ClientID   string    `json:"ClientID"`
+	ClientName string    `json:"ClientName"`
+	Scopes     []string  `json:"Scopes"`
+	CreatedAt  time.Time `json:"CreatedAt"`
+	UpdatedAt  time.Time `json:"UpdatedAt"`
+}
+
+type ConsentRepository interface {
+	GetConsent(ctx context.Context, userID int, clientID string) (ConsentGrant, error)
+	// SaveConsent створює або замінює набір дозволів для пари користувач-клієнт.
+	SaveConsent(ctx context.Context, grant ConsentGrant) error
+	ListConsents(ctx context.Context, userID int) ([]ConsentGrant, error)
+	RevokeConsent(ctx context.Context, userID int, clientID string) error
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// KnownDevice — пристрій, з якого користувач уже входив.
+// Fingerprint будується з user agent та префікса IP-адреси.
+type KnownDevice struct {
+	UserID      int
+	Fingerprint string
+	UserAgent   string
+	IPPrefix    string
+	Country     string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+type DeviceRepository interface {
+	CountDevices(ctx context.Context, userID int) (int, error)
+	IsKnownDevice(ctx context.Context, userID int, fingerprint string) (bool, error)
+	IsKnownCountry(ctx context.Context, userID int, country string) (bool, error)
+	// RememberDevice додає пристрій або оновлює last_seen_at вже відомого.
+	RememberDevice(ctx context.Context, device KnownDevice) error
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Види помилок. Конкретні помилки сервісів і репозиторіїв належать до одного з них,
+// тож обробники обирають HTTP-статус через errors.Is, не знаючи про конкретну сутність.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// kindError — помилка з власним текстом, що належить до виду kind.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Unwrap() error { return e.kind }
+
+// NewError створює помилку виду kind (ErrNotFound, ErrConflict, ...) з текстом для журналу.
+func NewError(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// FieldConflictError — значення унікального поля вже зайняте.
+// Err — загальніша помилка конфлікту (напр. ErrUserExists), через неї errors.Is бачить ErrConflict.
+type FieldConflictError struct {
+	Field   string
+	Message string
+	Err     error
+}
+
+func (e *FieldConflictError) Error() string { return e.Field + ": " + e.Message }
+
+func (e *FieldConflictError) Unwrap() error { return e.Err }
+
+// FieldError описує проблему з одним полем запиту. Code — правило валідації (Rule*),
+// за ним і Params клієнт або каталог перекладів будує власний текст; Message — текст за замовчуванням.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Params  Params `json:"params,omitempty"`
+}
+
+// NewFieldError створює помилку поля з текстом за замовчуванням для правила code.
+func NewFieldError(field, code string, params Params) FieldError {
+	return FieldError{Field: field, Code: code, Message: RuleMessage(code, params), Params: params}
+}
+
+// ValidationError збирає помилки полів; errors.Is(err, ErrValidation) для неї істинне.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError створює помилку з однією проблемою поля.
+func NewValidationError(field, code string, params Params) *ValidationError {
+	return (&ValidationError{}).Add(field, code, params)
+}
+
+// Add додає проблему поля і повертає ту саму помилку для ланцюжка викликів.
+func (e *ValidationError) Add(field, code string, params Params) *ValidationError {
+	e.Fields = append(e.Fields, NewFieldError(field, code, params))
+	return e
+}
+
+// OrNil повертає nil, якщо жодне поле не має проблем, щоб не загубити типізований nil в error.
+func (e *ValidationError) OrNil() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	ErrIdentityNotFound = NewError(ErrNotFound, "identity not found")
+	// ErrIdentityExists — цей обліковий запис провайдера вже прив'язано.
+	ErrIdentityExists = NewError(ErrConflict, "identity already linked")
+)
+
+// UserIdentity пов'язує користувача з обліковим записом зовнішнього провайдера (Google, Apple, Facebook).
+type UserIdentity struct {
+	UserID    int       `json:"-"`
+	Provider  string    `json:"Provider"`
+	Subject   string    `json:"-"`
+	Email     string    `json:"Email"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+type IdentityRepository interface {
+	GetIdentity(ctx context.Context, provider, subject string) (UserIdentity, error)
+	ListIdentities(ctx context.Context, userID int) ([]UserIdentity, error)
+	LinkIdentity(ctx context.Context, identity UserIdentity) error
+	UnlinkIdentity(ctx context.Context, userID int, provider string) error
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	GrantTypeDeviceCode    = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Типи токенів для обміну (RFC 8693).
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+type OAuthClient struct {
+	ClientID   string
+	Name       string
+	Secret     string
+	Public     bool
+	GrantTypes []string
+	// Scopes — дозволи, які клієнт може запитувати.
+	Scopes []string
+	// Trusted — власні застосунки CarVia: згода користувача не потрібна.
+	Trusted bool
+	// Audiences — сервіси, для яких клієнт може обміняти токен користувача.
+	Audiences []string
+}
+
+func (c OAuthClient) AllowsGrant(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeviceAuthorizationStatus string
+
+const (
+	DeviceAuthPending  DeviceAuthorizationStatus = "pending"
+	DeviceAuthApproved DeviceAuthorizationStatus = "approved"
+	DeviceAuthDenied   DeviceAuthorizationStatus = "denied"
+	DeviceAuthConsumed DeviceAuthorizationStatus = "consumed"
+)
+
+var ErrDeviceAuthorizationNotFound = NewError(ErrNotFound, "device authorization not found")
+
+// DeviceAuthorization — запит пристрою (RFC 8628). Device code зберігається лише як хеш.
+type DeviceAuthorization struct {
+	DeviceCodeHash string
+	UserCode       string
+	ClientID       string
+	Scope          string
+	Status         DeviceAuthorizationStatus
+	UserID         *int
+	Interval       time.Duration
+	ExpiresAt      time.Time
+	LastPolledAt   *time.Time
+	CreatedAt      time.Time
+}
+
+type DeviceAuthorizationRepository interface {
+	CreateDeviceAuthorization(ctx context.Context, auth DeviceAuthorization) error
+	GetByDeviceCode(ctx context.Context, deviceCodeHash string) (DeviceAuthorization, error)
+	GetByUserCode(ctx context.Context, userCode string) (DeviceAuthorization, error)
+	// DecideDeviceAuthorization змінює статус лише запиту, що ще очікує рішення.
+	DecideDeviceAuthorization(ctx context.Context, userCode string, status DeviceAuthorizationStatus, userID int) error
+	RecordDevicePoll(ctx context.Context, deviceCodeHash string, polledAt time.Time, interval time.Duration) error
+	// ConsumeDeviceAuthorization атомарно переводить схвалений запит у consumed; false — якщо його вже використано.
+	ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) (bool, error)
+}
+
+// OAuthError — помилка протоколу OAuth з кодом з RFC 6749 / RFC 8628.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+var (
+	ErrInvalidRequest       = &OAuthError{Code: "invalid_request"}
+	ErrInvalidClient        = &OAuthError{Code: "invalid_client"}
+	ErrInvalidGrant         = &OAuthError{Code: "invalid_grant"}
+	ErrUnauthorizedClient   = &OAuthError{Code: "unauthorized_client"}
+	ErrUnsupportedGrantType = &OAuthError{Code: "unsupported_grant_type"}
+	ErrAuthorizationPending = &OAuthError{Code: "authorization_pending"}
+	ErrSlowDown             = &OAuthError{Code: "slow_down"}
+	ErrAccessDenied         = &OAuthError{Code: "access_denied"}
+	ErrExpiredToken         = &OAuthError{Code: "expired_token"}
+	ErrInvalidScope         = &OAuthError{Code: "invalid_scope"}
+	ErrInvalidTarget        = &OAuthError{Code: "invalid_target"}
+)
+
+// TokenExchangeRequest — параметри grant_type=token-exchange (RFC 8693).
+type TokenExchangeRequest struct {
+	SubjectToken       string
+	SubjectTokenType   string
+	Audience           string
+	Scope              string
+	RequestedTokenType string
+}
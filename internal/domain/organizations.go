@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var ErrOrganizationNotFound = NewError(ErrNotFound, "organization not found")
+
+// Organization — компанія-партнер (лізинг, автопарк), до якої належать її співробітники.
+type Organization struct {
+	OrgID     int       `json:"OrgID"`
+	Slug      string    `json:"Slug"`
+	Name      string    `json:"Name"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+type OrganizationRepository interface {
+	GetOrganization(ctx context.Context, orgID int) (Organization, error)
+	// EnsureOrganization повертає організацію за slug, створюючи її за потреби.
+	EnsureOrganization(ctx context.Context, slug, name string) (Organization, error)
+}
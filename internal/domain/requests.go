@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type RegisterRequest struct {
 	UserID      int    `json:"-"`
 	Login       string `json:"Login"`
@@ -12,9 +14,14 @@ type RegisterRequest struct {
 	Address     string `json:"Address"`
 }
 
+const SessionModeCookie = "cookie"
+
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name"`
+	// SessionMode "cookie" видає браузерну сесію в HttpOnly cookie замість токена в тілі.
+	SessionMode string `json:"session_mode"`
 }
 
 type UserUpdateRequest struct {
@@ -29,3 +36,47 @@ type UserUpdateRequest struct {
 	Address      string `json:"Address"`
 	AvatarPath   string `json:"AvatarPath"`
 }
+
+type DeactivateRequest struct {
+	Password string `json:"password"`
+}
+
+type SuspendRequest struct {
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"`
+}
+
+type ConsentDecisionRequest struct {
+	ClientID string   `json:"client_id"`
+	Scopes   []string `json:"scopes"`
+	Approve  bool     `json:"approve"`
+}
+
+type ReauthenticateRequest struct {
+	Password string `json:"password"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type DeviceVerifyRequest struct {
+	UserCode string `json:"user_code"`
+	Approve  bool   `json:"approve"`
+}
+
+type CreateAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	OrgID     *int       `json:"org_id"`
+}
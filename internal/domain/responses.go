@@ -1,5 +1,70 @@
 package domain
 
+import "time"
+
 type TokenResponse struct {
 	Token string `json:"token"`
 }
+
+// CookieSessionResponse — відповідь входу в режимі cookie: сам токен у тіло не потрапляє.
+type CookieSessionResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AuthURLResponse struct {
+	URL string `json:"url"`
+}
+
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type DeviceVerificationResponse struct {
+	UserCode   string      `json:"user_code"`
+	ClientID   string      `json:"client_id"`
+	ClientName string      `json:"client_name"`
+	Scope      string      `json:"scope,omitempty"`
+	Scopes     []ScopeInfo `json:"scopes"`
+	ExpiresAt  string      `json:"expires_at"`
+}
+
+// ConsentPromptResponse — дані для екрана згоди: хто просить доступ і до чого.
+type ConsentPromptResponse struct {
+	ClientID   string      `json:"client_id"`
+	ClientName string      `json:"client_name"`
+	Requested  []ScopeInfo `json:"requested"`
+	Granted    []string    `json:"granted"`
+	// Required — чи потрібно показувати екран згоди, або всі дозволи вже надані.
+	Required bool `json:"required"`
+}
+
+// OAuthTokenResponse — відповідь /api/sso/token у форматі RFC 6749.
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	// IssuedTokenType заповнюється лише при обміні токена.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// CreatedAccessTokenResponse містить сам токен — єдиний раз, коли він доступний.
+type CreatedAccessTokenResponse struct {
+	Token string              `json:"token"`
+	Info  PersonalAccessToken `json:"info"`
+}
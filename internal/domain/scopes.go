@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// Дозволи, які користувач надає застосункам і токенам.
+const (
+	ScopeProfile        = "profile"
+	ScopeEmail          = "email"
+	ScopePhone          = "phone"
+	ScopeAddress        = "address"
+	ScopeListingsRead   = "listings:read"
+	ScopeListingsWrite  = "listings:write"
+	ScopeInventoryRead  = "inventory:read"
+	ScopeInventoryWrite = "inventory:write"
+)
+
+// ScopeInfo — опис дозволу для екрана згоди.
+type ScopeInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var Scopes = []ScopeInfo{
+	{Name: ScopeProfile, Description: "Ім'я, прізвище, логін та аватар"},
+	{Name: ScopeEmail, Description: "Адреса електронної пошти"},
+	{Name: ScopePhone, Description: "Номер телефону"},
+	{Name: ScopeAddress, Description: "Адреса"},
+	{Name: ScopeListingsRead, Description: "Перегляд ваших оголошень"},
+	{Name: ScopeListingsWrite, Description: "Створення та зміна оголошень від вашого імені"},
+	{Name: ScopeInventoryRead, Description: "Перегляд складу автомобілів"},
+	{Name: ScopeInventoryWrite, Description: "Зміна складу автомобілів"},
+}
+
+func LookupScope(name string) (ScopeInfo, bool) {
+	for _, scope := range Scopes {
+		if scope.Name == name {
+			return scope, true
+		}
+	}
+	return ScopeInfo{}, false
+}
+
+// ParseScope розбирає рядок дозволів через пробіл (RFC 6749), прибираючи повтори.
+func ParseScope(scope string) []string {
+	scopes := []string{}
+	for _, name := range strings.Fields(scope) {
+		if !slices.Contains(scopes, name) {
+			scopes = append(scopes, name)
+		}
+	}
+	return scopes
+}
+
+func JoinScopes(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = NewError(ErrNotFound, "session not found")
+	// ErrSessionExpired — минув абсолютний строк сесії, токени за нею більше не оновлюються.
+	ErrSessionExpired = NewError(ErrUnauthorized, "session expired")
+	// ErrRefreshReused — токен оновлення пред'явлено вдруге; сесію вже відкликано.
+	ErrRefreshReused = NewError(ErrUnauthorized, "refresh token reused")
+)
+
+type Session struct {
+	ID         string     `json:"ID"`
+	UserID     int        `json:"-"`
+	DeviceName string     `json:"DeviceName"`
+	UserAgent  string     `json:"UserAgent"`
+	IP         string     `json:"IP"`
+	CreatedAt  time.Time  `json:"CreatedAt"`
+	LastSeenAt time.Time  `json:"LastSeenAt"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"Current"`
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session Session) error
+	GetSession(ctx context.Context, sessionID string) (Session, error)
+	ListActiveSessions(ctx context.Context, userID int) ([]Session, error)
+	TouchSession(ctx context.Context, sessionID string, ip, userAgent string, seenAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeUserSessions(ctx context.Context, userID int) error
+}
@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// UsedTokenRepository запам'ятовує використані одноразові токени (посилання з листів, SAML-твердження)
+// до закінчення їхньої дії — далі підпис чи термін уже не пропустять їх самі.
+type UsedTokenRepository interface {
+	// MarkUsed позначає токен використаним; false означає, що його вже було використано раніше.
+	MarkUsed(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+}
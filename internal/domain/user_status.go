@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type UserStatus string
+
+const (
+	UserStatusPendingVerification UserStatus = "pending_verification"
+	UserStatusActive              UserStatus = "active"
+	UserStatusSuspended           UserStatus = "suspended"
+	UserStatusDeactivated         UserStatus = "deactivated"
+	UserStatusDeleted             UserStatus = "deleted"
+)
+
+func (s UserStatus) Valid() bool {
+	switch s {
+	case UserStatusPendingVerification, UserStatusActive, UserStatusSuspended,
+		UserStatusDeactivated, UserStatusDeleted:
+		return true
+	}
+	return false
+}
+
+// EffectiveStatus враховує автоматичне зняття блокування після StatusUntil.
+func (u User) EffectiveStatus(now time.Time) UserStatus {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	if u.Status == UserStatusSuspended && u.StatusUntil != nil && !now.Before(*u.StatusUntil) {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+// UserStatusError повертається, коли статус користувача не дозволяє автентифікацію.
+type UserStatusError struct {
+	Status UserStatus
+	Reason string
+	Until  *time.Time
+}
+
+func (e *UserStatusError) Error() string {
+	if e.Until != nil {
+		return fmt.Sprintf("user is %s until %s", e.Status, e.Until.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("user is %s", e.Status)
+}
@@ -1,6 +1,39 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrUserNotFound = NewError(ErrNotFound, "user not found")
+	// ErrUserExists — логін або email уже зайняті іншим користувачем.
+	ErrUserExists = NewError(ErrConflict, "user already exists")
+	// ErrEmailTaken і ErrLoginTaken уточнюють ErrUserExists полем, що конфліктує
+	// (назва поля — як у RegisterRequest і UserUpdateRequest).
+	ErrEmailTaken = &FieldConflictError{Field: "Email", Message: "email already registered", Err: ErrUserExists}
+	ErrLoginTaken = &FieldConflictError{Field: "Login", Message: "login already taken", Err: ErrUserExists}
+	// ErrInvalidPassword — пароль не збігається з поточним.
+	ErrInvalidPassword = NewError(ErrUnauthorized, "invalid password")
+)
+
+// NormalizeEmail зводить email до канонічного вигляду: NFKC, без пробілів по краях, нижній регістр.
+// Так зберігаються і так шукаються email, тому "Ivan@Mail.com " і "ivan@mail.com" — один користувач.
+func NormalizeEmail(email string) string {
+	return normalizeIdentifier(email)
+}
+
+// NormalizeLogin зводить логін до канонічного вигляду за тими ж правилами, що й email.
+func NormalizeLogin(login string) string {
+	return normalizeIdentifier(login)
+}
+
+func normalizeIdentifier(s string) string {
+	return strings.ToLower(strings.TrimSpace(norm.NFKC.String(s)))
+}
 
 type User struct {
 	UserID       int    `json:"UserID"`
@@ -13,14 +46,37 @@ type User struct {
 	Address      string `json:"Address"`
 	Phonenumber  string `json:"Phonenumber"`
 	AvatarPath   string `json:"AvatarPath"`
+
+	Status       UserStatus `json:"Status"`
+	StatusReason string     `json:"StatusReason,omitempty"`
+	StatusUntil  *time.Time `json:"StatusUntil,omitempty"`
+
+	PasswordResetRequired bool `json:"PasswordResetRequired"`
+	OrgID                 *int `json:"OrgID,omitempty"`
+}
+
+// Roles повертає ролі користувача для claim roles.
+func (u User) Roles() []string {
+	if u.Role == "" {
+		return nil
+	}
+	return []string{u.Role}
 }
 
+// UserRepository повертає ErrUserNotFound для відсутнього користувача
+// та ErrUserExists, якщо логін або email уже зайняті.
 type UserRepository interface {
 	CreateUser(ctx context.Context, user User) (int, error)
+	GetByID(ctx context.Context, userID int) (User, error)
 	GetByEmail(ctx context.Context, email string) (User, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	GetByUsername(ctx context.Context, username string) (User, error)
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 
 	UpdateUserProfile(ctx context.Context, userData UserUpdateRequest) error
+	UpdateUserStatus(ctx context.Context, userID int, status UserStatus, reason string, until *time.Time) error
+	SetOrganization(ctx context.Context, userID, orgID int) error
+	SetPasswordResetRequired(ctx context.Context, userID int, required bool) error
+	// UpdatePassword змінює хеш пароля і знімає вимогу скидання.
+	UpdatePassword(ctx context.Context, userID int, hashPassword string) error
 }
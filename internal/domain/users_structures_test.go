@@ -0,0 +1,23 @@
+package domain
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"ivan@carvia.test", "ivan@carvia.test"},
+		{"  Ivan@CarVia.Test\t", "ivan@carvia.test"},
+		{"ｉｖａｎ＠ｃａｒｖｉａ.test", "ivan@carvia.test"},
+		{"Олена@Carvia.Test", "олена@carvia.test"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.in); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLogin(t *testing.T) {
+	if got := NormalizeLogin(" Driver１ "); got != "driver1" {
+		t.Errorf("NormalizeLogin = %q, want driver1", got)
+	}
+}
@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Коди правил валідації. Вони стабільні: клієнти й каталог перекладів обирають за ними текст.
+const (
+	RuleRequired     = "required"
+	RuleTooShort     = "too_short"
+	RuleTooLong      = "too_long"
+	RuleInvalidEmail = "invalid_email"
+	RuleInvalidPhone = "invalid_phone"
+	RuleInvalidChars = "invalid_characters"
+	RuleUnknownValue = "unknown_value"
+	RuleNotInFuture  = "not_in_future"
+	RuleNotMember    = "not_member"
+	RuleTaken        = "taken"
+	RuleInvalid      = "invalid"
+)
+
+// Params — значення для підстановки в текст помилки: {min}, {max}, {value}...
+type Params map[string]any
+
+// ruleMessages — тексти помилок за замовчуванням (українською).
+var ruleMessages = map[string]string{
+	RuleRequired:     "Поле обов'язкове",
+	RuleTooShort:     "Мінімальна довжина — {min} символів",
+	RuleTooLong:      "Максимальна довжина — {max} символів",
+	RuleInvalidEmail: "Неправильний формат email",
+	RuleInvalidPhone: "Неправильний номер телефону, очікується формат +380XXXXXXXXX",
+	RuleInvalidChars: "Поле містить недозволені символи",
+	RuleUnknownValue: "Невідоме значення {value}",
+	RuleNotInFuture:  "Дата має бути в майбутньому",
+	RuleNotMember:    "Користувач не належить до організації {org_id}",
+	RuleTaken:        "Значення вже зайняте",
+	RuleInvalid:      "Неправильне значення",
+}
+
+// RuleMessage повертає текст за замовчуванням для правила з підставленими params.
+func RuleMessage(code string, params Params) string {
+	message, ok := ruleMessages[code]
+	if !ok {
+		message = ruleMessages[RuleInvalid]
+	}
+	return FormatMessage(message, params)
+}
+
+// FormatMessage підставляє params у шаблон виду "Максимальна довжина — {max} символів".
+func FormatMessage(template string, params Params) string {
+	if len(params) == 0 {
+		return template
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// Rule перевіряє непорожнє значення поля і повертає код порушеного правила з параметрами.
+type Rule func(value string) (code string, params Params, ok bool)
+
+// Check перевіряє поле правилами по черзі й записує першу ж помилку.
+// Порожнє значення перевіряється лише на Required: необов'язкові поля можна не заповнювати.
+func (e *ValidationError) Check(field, value string, required bool, rules ...Rule) *ValidationError {
+	if value == "" {
+		if required {
+			e.Add(field, RuleRequired, nil)
+		}
+		return e
+	}
+	for _, rule := range rules {
+		if code, params, ok := rule(value); !ok {
+			e.Add(field, code, params)
+			break
+		}
+	}
+	return e
+}
+
+// Length обмежує довжину в символах (не байтах).
+func Length(min, max int) Rule {
+	return func(value string) (string, Params, bool) {
+		n := utf8.RuneCountInString(value)
+		switch {
+		case n < min:
+			return RuleTooShort, Params{"min": min}, false
+		case n > max:
+			return RuleTooLong, Params{"max": max}, false
+		}
+		return "", nil, true
+	}
+}
+
+// MaxBytes обмежує довжину в байтах — для паролів, бо bcrypt ігнорує все після 72 байтів.
+func MaxBytes(max int) Rule {
+	return func(value string) (string, Params, bool) {
+		if len(value) > max {
+			return RuleTooLong, Params{"max": max}, false
+		}
+		return "", nil, true
+	}
+}
+
+// Chars дозволяє лише символи, для яких allowed істинний.
+func Chars(allowed func(r rune) bool) Rule {
+	return func(value string) (string, Params, bool) {
+		for _, r := range value {
+			if !allowed(r) {
+				return RuleInvalidChars, nil, false
+			}
+		}
+		return "", nil, true
+	}
+}
+
+// Email перевіряє синтаксис адреси без імені відправника: "ivan@carvia.ua", але не "Іван <ivan@carvia.ua>".
+func Email(value string) (string, Params, bool) {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || !strings.Contains(value[strings.LastIndex(value, "@"):], ".") {
+		return RuleInvalidEmail, nil, false
+	}
+	return "", nil, true
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// Phone перевіряє номер у міжнародному форматі; пробіли, дужки й дефіси ігноруються.
+func Phone(value string) (string, Params, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '(' || r == ')' {
+			return -1
+		}
+		return r
+	}, value)
+	if !phonePattern.MatchString(digits) {
+		return RuleInvalidPhone, nil, false
+	}
+	return "", nil, true
+}
+
+// LoginChars — латиниця й кирилиця, цифри, "_", "." і "-".
+func LoginChars(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+// NameChars — літери, пробіл, дефіс і апострофи, як у "Анна-Марія" чи "Д'Артаньян".
+func NameChars(r rune) bool {
+	return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) || r == ' ' || r == '-' || r == '\'' || r == '’' || r == 'ʼ'
+}
+
+// TextChars відкидає керівні символи, але дозволяє решту тексту (адреси тощо).
+func TextChars(r rune) bool {
+	return !unicode.IsControl(r)
+}
+
+// Обмеження полів користувача.
+const (
+	minLoginLength    = 3
+	maxLoginLength    = 32
+	maxEmailLength    = 254
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+	maxNameLength     = 100
+	maxAddressLength  = 255
+)
+
+// passwordRules — правила нового пароля; MaxBytes, бо bcrypt обрізає довші паролі.
+var passwordRules = []Rule{Length(minPasswordLength, maxPasswordBytes), MaxBytes(maxPasswordBytes)}
+
+// Validate перевіряє дані реєстрації. Логін і email перевіряються в нормалізованому вигляді,
+// тобто так, як їх буде збережено.
+func (r RegisterRequest) Validate() error {
+	return (&ValidationError{}).
+		Check("Login", NormalizeLogin(r.Login), true, Length(minLoginLength, maxLoginLength), Chars(LoginChars)).
+		Check("Email", NormalizeEmail(r.Email), true, Length(1, maxEmailLength), Email).
+		Check("Password", r.Password, true, passwordRules...).
+		Check("FirstName", strings.TrimSpace(r.FirstName), true, Length(1, maxNameLength), Chars(NameChars)).
+		Check("LastName", strings.TrimSpace(r.LastName), true, Length(1, maxNameLength), Chars(NameChars)).
+		Check("Phonenumber", strings.TrimSpace(r.Phonenumber), false, Phone).
+		Check("Address", strings.TrimSpace(r.Address), false, Length(1, maxAddressLength), Chars(TextChars)).
+		OrNil()
+}
+
+// Validate перевіряє лише наявність і розмір полів: правила складності пароля
+// при вході не застосовуються, щоб не підказувати політику й не відсікати старі паролі.
+func (r LoginRequest) Validate() error {
+	return (&ValidationError{}).
+		Check("email", NormalizeEmail(r.Email), true, Length(1, maxEmailLength)).
+		Check("password", r.Password, true, MaxBytes(maxPasswordBytes)).
+		Check("device_name", r.DeviceName, false, Length(1, maxNameLength), Chars(TextChars)).
+		OrNil()
+}
+
+// Validate застосовує до нового пароля ті самі правила, що й при реєстрації.
+func (r PasswordResetConfirmRequest) Validate() error {
+	return (&ValidationError{}).
+		Check("token", r.Token, true).
+		Check("password", r.Password, true, passwordRules...).
+		OrNil()
+}
+
+// ValidatePassword перевіряє новий пароль, встановлений поза формами (CLI, адміністратор).
+func ValidatePassword(password string) error {
+	return (&ValidationError{}).Check("password", password, true, passwordRules...).OrNil()
+}
+
+// Validate перевіряє оновлення профілю; усі поля, крім аватара й пароля, обов'язкові.
+// Порожній пароль лишає поточний.
+func (r UserUpdateRequest) Validate() error {
+	return (&ValidationError{}).
+		Check("Login", NormalizeLogin(r.Login), true, Length(minLoginLength, maxLoginLength), Chars(LoginChars)).
+		Check("Email", NormalizeEmail(r.Email), true, Length(1, maxEmailLength), Email).
+		Check("Password", r.Password, false, passwordRules...).
+		Check("FirstName", strings.TrimSpace(r.FirstName), true, Length(1, maxNameLength), Chars(NameChars)).
+		Check("LastName", strings.TrimSpace(r.LastName), true, Length(1, maxNameLength), Chars(NameChars)).
+		Check("Phonenumber", strings.TrimSpace(r.Phonenumber), true, Phone).
+		Check("Address", strings.TrimSpace(r.Address), true, Length(1, maxAddressLength), Chars(TextChars)).
+		OrNil()
+}
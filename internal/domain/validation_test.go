@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validRegisterRequest() RegisterRequest {
+	return RegisterRequest{
+		Login:       "ivan.petrenko",
+		Email:       "ivan@carvia.test",
+		Password:    "correct-horse",
+		FirstName:   "Іван",
+		LastName:    "Д'Артаньян",
+		Phonenumber: "+38 (050) 111-22-33",
+		Address:     "Київ, вул. Хрещатик, 1",
+	}
+}
+
+func TestRegisterRequestValidate(t *testing.T) {
+	if err := validRegisterRequest().Validate(); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(r *RegisterRequest)
+		field string
+		code  string
+	}{
+		{"EmptyLogin", func(r *RegisterRequest) { r.Login = "" }, "Login", RuleRequired},
+		{"LoginChars", func(r *RegisterRequest) { r.Login = "ivan; drop" }, "Login", RuleInvalidChars},
+		{"LongLogin", func(r *RegisterRequest) { r.Login = strings.Repeat("a", 33) }, "Login", RuleTooLong},
+		{"EmailWithName", func(r *RegisterRequest) { r.Email = "Ivan <ivan@carvia.test>" }, "Email", RuleInvalidEmail},
+		{"EmailWithoutDomain", func(r *RegisterRequest) { r.Email = "ivan@localhost" }, "Email", RuleInvalidEmail},
+		{"ShortPassword", func(r *RegisterRequest) { r.Password = "short" }, "Password", RuleTooShort},
+		{"PasswordOverBcryptLimit", func(r *RegisterRequest) { r.Password = strings.Repeat("я", 40) }, "Password", RuleTooLong},
+		{"HugeName", func(r *RegisterRequest) { r.FirstName = strings.Repeat("а", 10_000) }, "FirstName", RuleTooLong},
+		{"NameDigits", func(r *RegisterRequest) { r.LastName = "R2D2" }, "LastName", RuleInvalidChars},
+		{"Phone", func(r *RegisterRequest) { r.Phonenumber = "12-34" }, "Phonenumber", RuleInvalidPhone},
+		{"AddressControlChars", func(r *RegisterRequest) { r.Address = "Київ\x00" }, "Address", RuleInvalidChars},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRegisterRequest()
+			tt.edit(&req)
+
+			var invalid *ValidationError
+			if !errors.As(req.Validate(), &invalid) {
+				t.Fatalf("Validate() = %v, want ValidationError", req.Validate())
+			}
+			if len(invalid.Fields) != 1 || invalid.Fields[0].Field != tt.field || invalid.Fields[0].Code != tt.code {
+				t.Errorf("fields = %+v, want %s %s", invalid.Fields, tt.field, tt.code)
+			}
+		})
+	}
+}
+
+func TestRegisterRequestOptionalFields(t *testing.T) {
+	req := validRegisterRequest()
+	req.Phonenumber, req.Address = "", ""
+	if err := req.Validate(); err != nil {
+		t.Errorf("phone and address are optional on registration: %v", err)
+	}
+}
+
+func TestRuleMessage(t *testing.T) {
+	if got := RuleMessage(RuleTooLong, Params{"max": 32}); got != "Максимальна довжина — 32 символів" {
+		t.Errorf("RuleMessage = %q", got)
+	}
+}
@@ -0,0 +1,259 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sso-service/internal/config"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Identity — користувач, підтверджений зовнішнім провайдером.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	// Login, Phonenumber та Address заповнюються лише з атрибутів SAML.
+	Login       string
+	Phonenumber string
+	Address     string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(state, nonce string) string
+	Exchange(ctx context.Context, code, nonce string) (Identity, error)
+}
+
+// NewProviders створює провайдерів з конфігурації. Провайдери без client_id пропускаються,
+// а ті, для яких не вдалося виконати OIDC discovery, лише логуються, щоб сервіс стартував.
+func NewProviders(ctx context.Context, cfgs map[string]config.OAuthProviderConfig, publicURL string) map[string]Provider {
+	providers := make(map[string]Provider)
+
+	for name, cfg := range cfgs {
+		if cfg.ClientID == "" {
+			continue
+		}
+
+		redirectURL := fmt.Sprintf("%s/api/sso/oauth/%s/callback", publicURL, name)
+
+		var provider Provider
+		var err error
+
+		switch cfg.Type {
+		case "oidc":
+			provider, err = newOIDCProvider(ctx, name, cfg, redirectURL)
+		case "oauth2":
+			provider = newOAuth2Provider(name, cfg, redirectURL)
+		default:
+			err = fmt.Errorf("unknown provider type %q", cfg.Type)
+		}
+
+		if err != nil {
+			slog.Error("Не вдалося налаштувати провайдера входу", "provider", name, "err", err.Error())
+			continue
+		}
+
+		providers[name] = provider
+	}
+
+	return providers
+}
+
+type oidcProvider struct {
+	name         string
+	oauth        oauth2.Config
+	verifier     *oidc.IDTokenVerifier
+	responseMode string
+	trustEmail   bool
+}
+
+func newOIDCProvider(ctx context.Context, name string, cfg config.OAuthProviderConfig, redirectURL string) (*oidcProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &oidcProvider{
+		name: name,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		},
+		verifier:     provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		responseMode: cfg.ResponseMode,
+		trustEmail:   cfg.TrustEmail,
+	}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, nonce string) string {
+	opts := []oauth2.AuthCodeOption{oidc.Nonce(nonce)}
+	if p.responseMode != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("response_mode", p.responseMode))
+	}
+	return p.oauth.AuthCodeURL(state, opts...)
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce string) (Identity, error) {
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, fmt.Errorf("provider did not return id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, err
+	}
+	if idToken.Nonce != nonce {
+		return Identity{}, fmt.Errorf("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, err
+	}
+
+	return Identity{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: p.trustEmail || isTrue(claims.EmailVerified),
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+	}, nil
+}
+
+// isTrue приймає email_verified як bool або рядок — Apple повертає "true".
+func isTrue(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+type oauth2Provider struct {
+	name        string
+	oauth       oauth2.Config
+	userInfoURL string
+	trustEmail  bool
+}
+
+func newOAuth2Provider(name string, cfg config.OAuthProviderConfig, redirectURL string) *oauth2Provider {
+	return &oauth2Provider{
+		name: name,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     oauth2.Endpoint{AuthURL: cfg.AuthURL, TokenURL: cfg.TokenURL},
+			RedirectURL:  redirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		userInfoURL: cfg.UserInfoURL,
+		trustEmail:  cfg.TrustEmail,
+	}
+}
+
+func (p *oauth2Provider) Name() string {
+	return p.name
+}
+
+func (p *oauth2Provider) AuthCodeURL(state, _ string) string {
+	return p.oauth.AuthCodeURL(state)
+}
+
+func (p *oauth2Provider) Exchange(ctx context.Context, code, _ string) (Identity, error) {
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	resp, err := p.oauth.Client(ctx, token).Get(p.userInfoURL)
+	if err != nil {
+		return Identity{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Identity{}, fmt.Errorf("userinfo returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info struct {
+		ID            any    `json:"id"`
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		FirstName     string `json:"first_name"`
+		LastName      string `json:"last_name"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&info); err != nil {
+		return Identity{}, err
+	}
+
+	identity := Identity{
+		Provider:      p.name,
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: p.trustEmail || isTrue(info.EmailVerified),
+		FirstName:     firstNonEmpty(info.FirstName, info.GivenName),
+		LastName:      firstNonEmpty(info.LastName, info.FamilyName),
+	}
+	if identity.Subject == "" && info.ID != nil {
+		identity.Subject = fmt.Sprint(info.ID)
+	}
+	if identity.Subject == "" {
+		return Identity{}, fmt.Errorf("userinfo has no subject")
+	}
+	identity.Email = strings.TrimSpace(identity.Email)
+
+	return identity, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+package federation
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sso-service/internal/config"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+)
+
+// SAMLPartner — наш service provider для IdP одного партнера.
+type SAMLPartner struct {
+	Name           string
+	Organization   string
+	AllowedDomains []string
+	attributes     map[string]string
+	sp             *saml.ServiceProvider
+}
+
+// NewSAMLPartners завантажує ключ SP і метадані IdP партнерів. Без ключа SAML вимкнено,
+// партнери з недоступними метаданими чи без allowed_domains лише логуються і не підключаються.
+func NewSAMLPartners(ctx context.Context, cfg config.SAMLConfig, publicURL string) map[string]*SAMLPartner {
+	partners := make(map[string]*SAMLPartner)
+
+	if len(cfg.Partners) == 0 {
+		return partners
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+	if err != nil {
+		slog.Error("SAML вимкнено: не вдалося завантажити ключ SP", "err", err.Error())
+		return partners
+	}
+
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		slog.Error("SAML вимкнено: неправильний сертифікат SP", "err", err.Error())
+		return partners
+	}
+
+	for name, partnerCfg := range cfg.Partners {
+		// Без переліку доменів IdP партнера міг би створити обліковий запис на будь-який email.
+		if len(partnerCfg.AllowedDomains) == 0 {
+			slog.Error("SAML-партнера не підключено: не задано allowed_domains", "partner", name)
+			continue
+		}
+
+		idpMetadata, err := loadIDPMetadata(ctx, partnerCfg)
+		if err != nil {
+			slog.Error("Не вдалося завантажити метадані IdP", "partner", name, "err", err.Error())
+			continue
+		}
+
+		base := fmt.Sprintf("%s/api/sso/saml/%s", publicURL, name)
+		metadataURL, _ := url.Parse(base + "/metadata")
+		acsURL, _ := url.Parse(base + "/acs")
+
+		partners[name] = &SAMLPartner{
+			Name:           name,
+			Organization:   partnerCfg.Organization,
+			AllowedDomains: partnerCfg.AllowedDomains,
+			attributes:     partnerCfg.Attributes,
+			sp: &saml.ServiceProvider{
+				EntityID:          metadataURL.String(),
+				Key:               keyPair.PrivateKey.(crypto.Signer),
+				Certificate:       cert,
+				MetadataURL:       *metadataURL,
+				AcsURL:            *acsURL,
+				IDPMetadata:       idpMetadata,
+				AllowIDPInitiated: partnerCfg.AllowIDPInitiated,
+				SignatureMethod:   "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256",
+			},
+		}
+	}
+
+	return partners
+}
+
+func loadIDPMetadata(ctx context.Context, cfg config.SAMLPartnerConfig) (*saml.EntityDescriptor, error) {
+	if cfg.MetadataPath != "" {
+		data, err := os.ReadFile(cfg.MetadataPath)
+		if err != nil {
+			return nil, err
+		}
+		return samlsp.ParseMetadata(data)
+	}
+
+	metadataURL, err := url.Parse(cfg.MetadataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return samlsp.FetchMetadata(ctx, http.DefaultClient, *metadataURL)
+}
+
+func (p *SAMLPartner) Metadata() ([]byte, error) {
+	return xml.MarshalIndent(p.sp.Metadata(), "", "  ")
+}
+
+// AuthRedirectURL формує AuthnRequest (HTTP-Redirect binding). relayState отримує ID запиту,
+// щоб його можна було перевірити у відповіді IdP.
+func (p *SAMLPartner) AuthRedirectURL(relayState func(requestID string) (string, error)) (string, error) {
+	req, err := p.sp.MakeAuthenticationRequest(p.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding),
+		saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := relayState(req.ID)
+	if err != nil {
+		return "", err
+	}
+
+	redirectURL, err := req.Redirect(state, p.sp)
+	if err != nil {
+		return "", err
+	}
+
+	return redirectURL.String(), nil
+}
+
+// Assertion — перевірене твердження IdP. ID та NotOnOrAfter потрібні, щоб не прийняти
+// те саме твердження двічі, поки воно ще чинне.
+type Assertion struct {
+	Identity     Identity
+	ID           string
+	NotOnOrAfter time.Time
+}
+
+// ParseResponse перевіряє підпис, аудиторію і термін дії відповіді IdP та відображає атрибути на Identity.
+func (p *SAMLPartner) ParseResponse(r *http.Request, requestIDs []string) (Assertion, error) {
+	assertion, err := p.sp.ParseResponse(r, requestIDs)
+	if err != nil {
+		if invalid, ok := err.(*saml.InvalidResponseError); ok {
+			return Assertion{}, invalid.PrivateErr
+		}
+		return Assertion{}, err
+	}
+
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		return Assertion{}, fmt.Errorf("assertion has no NameID")
+	}
+	if assertion.ID == "" {
+		return Assertion{}, fmt.Errorf("assertion has no ID")
+	}
+
+	values := make(map[string]string)
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if len(attr.Values) == 0 {
+				continue
+			}
+			values[attr.Name] = attr.Values[0].Value
+			if attr.FriendlyName != "" {
+				values[attr.FriendlyName] = attr.Values[0].Value
+			}
+		}
+	}
+
+	attribute := func(field string) string {
+		name, ok := p.attributes[field]
+		if !ok {
+			name = field
+		}
+		return strings.TrimSpace(values[name])
+	}
+
+	identity := Identity{
+		Provider:      "saml:" + p.Name,
+		Subject:       assertion.Subject.NameID.Value,
+		Email:         attribute("email"),
+		EmailVerified: true,
+		Login:         attribute("login"),
+		FirstName:     attribute("first_name"),
+		LastName:      attribute("last_name"),
+		Phonenumber:   attribute("phonenumber"),
+		Address:       attribute("address"),
+	}
+
+	if identity.Email == "" && strings.Contains(identity.Subject, "@") {
+		identity.Email = identity.Subject
+	}
+
+	return Assertion{Identity: identity, ID: assertion.ID, NotOnOrAfter: notOnOrAfter(assertion)}, nil
+}
+
+// notOnOrAfter — до якого часу бібліотека ще прийме твердження: найпізніша з меж Conditions
+// і SubjectConfirmationData з урахуванням допустимого розходження годинників.
+func notOnOrAfter(assertion *saml.Assertion) time.Time {
+	latest := assertion.IssueInstant.Add(saml.MaxIssueDelay)
+	if assertion.Conditions != nil && assertion.Conditions.NotOnOrAfter.After(latest) {
+		latest = assertion.Conditions.NotOnOrAfter
+	}
+	for _, confirmation := range assertion.Subject.SubjectConfirmations {
+		if data := confirmation.SubjectConfirmationData; data != nil && data.NotOnOrAfter.After(latest) {
+			latest = data.NotOnOrAfter
+		}
+	}
+	return latest.Add(saml.MaxClockSkew)
+}
+
+// EmailAllowed перевіряє, що домен email належить партнеру.
+func (p *SAMLPartner) EmailAllowed(email string) bool {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	for _, allowed := range p.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+// Package clientip визначає адресу та країну клієнта з урахуванням довірених проксі.
+// Заголовки X-Forwarded-For, X-Real-IP та заголовки країни беруться до уваги лише тоді, коли запит
+// прийшов від проксі зі списку: інакше будь-хто підставив би собі чужу адресу в журнал аудиту чи сесію
+// або видав себе за вже відомий пристрій.
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver знає, яким проксі можна вірити. Нульове значення не довіряє нікому.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver приймає адреси або мережі CIDR довірених проксі (напр. "10.0.0.0/8", "172.18.0.5").
+func NewResolver(trustedProxies []string) (*Resolver, error) {
+	res := &Resolver{}
+	for _, entry := range trustedProxies {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", entry, err)
+			}
+			res.trusted = append(res.trusted, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		res.trusted = append(res.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return res, nil
+}
+
+func (res *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range res.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IP повертає адресу клієнта. Якщо безпосередній співрозмовник — довірений проксі, ланцюжок
+// X-Forwarded-For читається справа наліво до першої адреси, яка не є довіреним проксі:
+// ліві елементи ланцюжка клієнт може дописати сам.
+func (res *Resolver) IP(r *http.Request) string {
+	peer, ok := parseAddr(remoteHost(r.RemoteAddr))
+	if !ok {
+		return remoteHost(r.RemoteAddr)
+	}
+	if !res.isTrusted(peer) {
+		return peer.String()
+	}
+
+	client := peer
+	hops := forwardedFor(r.Header)
+	if len(hops) == 0 {
+		if realIP, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+			return realIP.String()
+		}
+		return peer.String()
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseAddr(hops[i])
+		if !ok {
+			// Зіпсований елемент — далі ланцюжку вірити не можна, лишаємо останню перевірену адресу.
+			break
+		}
+		client = hop
+		if !res.isTrusted(hop) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedFor збирає адреси з усіх заголовків X-Forwarded-For у порядку проходження проксі.
+func forwardedFor(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func parseAddr(value string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// countryHeaders — заголовки, якими шлюз або CDN передає країну клієнта (ISO 3166-1 alpha-2).
+var countryHeaders = []string{"CF-IPCountry", "X-Country-Code"}
+
+// Country повертає країну клієнта з заголовків довіреного проксі або порожній рядок,
+// якщо країна невідома чи запит прийшов не через проксі.
+func (res *Resolver) Country(r *http.Request) string {
+	peer, ok := parseAddr(remoteHost(r.RemoteAddr))
+	if !ok || !res.isTrusted(peer) {
+		return ""
+	}
+	for _, header := range countryHeaders {
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(header))); validCountry(country) {
+			return country
+		}
+	}
+	return ""
+}
+
+// validCountry приймає лише дві латинські літери; "XX" Cloudflare ставить для невідомої країни.
+func validCountry(country string) bool {
+	if len(country) != 2 || country == "XX" {
+		return false
+	}
+	for _, c := range country {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Client — визначені дані клієнта запиту.
+type Client struct {
+	IP      string
+	Country string
+}
+
+// Resolve визначає дані клієнта запиту.
+func (res *Resolver) Resolve(r *http.Request) Client {
+	return Client{IP: res.IP(r), Country: res.Country(r)}
+}
+
+type contextKey struct{}
+
+// WithClient кладе визначені дані клієнта в контекст.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, contextKey{}, client)
+}
+
+// FromRequest повертає дані клієнта з контексту, а якщо middleware їх не поклав —
+// лише адресу безпосереднього співрозмовника, без жодних заголовків.
+func FromRequest(r *http.Request) Client {
+	if client, ok := r.Context().Value(contextKey{}).(Client); ok {
+		return client
+	}
+	return (&Resolver{}).Resolve(r)
+}
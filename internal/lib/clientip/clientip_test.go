@@ -0,0 +1,96 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolverIP(t *testing.T) {
+	res, err := NewResolver([]string{"10.0.0.0/8", "172.18.0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{"DirectIgnoresHeaders", "203.0.113.1:1234", []string{"198.51.100.9"}, "198.51.100.8", "203.0.113.1"},
+		{"TrustedProxy", "10.1.2.3:1234", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"ForgedLeftmost", "10.1.2.3:1234", []string{"1.1.1.1, 198.51.100.9"}, "", "198.51.100.9"},
+		{"ProxyChain", "172.18.0.5:1234", []string{"198.51.100.9, 10.0.0.4"}, "", "198.51.100.9"},
+		{"MultipleHeaders", "10.1.2.3:1234", []string{"1.1.1.1", "198.51.100.9"}, "", "198.51.100.9"},
+		{"AllTrusted", "10.1.2.3:1234", []string{"10.0.0.7, 10.0.0.4"}, "", "10.0.0.7"},
+		{"Garbage", "10.1.2.3:1234", []string{"198.51.100.9, not-an-ip"}, "", "10.1.2.3"},
+		{"RealIP", "10.1.2.3:1234", nil, "198.51.100.8", "198.51.100.8"},
+		{"NoHeaders", "10.1.2.3:1234", nil, "", "10.1.2.3"},
+		{"IPv6", "[2001:db8::1]:1234", []string{"198.51.100.9"}, "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := res.IP(req); got != tt.want {
+				t.Errorf("IP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolverInvalid(t *testing.T) {
+	for _, entry := range []string{"10.0.0.0/33", "gateway", ""} {
+		if _, err := NewResolver([]string{entry}); err == nil {
+			t.Errorf("NewResolver(%q) accepted an invalid proxy", entry)
+		}
+	}
+}
+
+func TestFromRequestWithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	if got := FromRequest(req).IP; got != "203.0.113.1" {
+		t.Errorf("FromRequest().IP = %q, want the peer address", got)
+	}
+}
+
+func TestResolverCountry(t *testing.T) {
+	res, err := NewResolver([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"DirectIgnoresHeaders", "203.0.113.1:1234", map[string]string{"CF-IPCountry": "UA"}, ""},
+		{"Cloudflare", "10.1.2.3:1234", map[string]string{"CF-IPCountry": "ua"}, "UA"},
+		{"Gateway", "10.1.2.3:1234", map[string]string{"X-Country-Code": "PL"}, "PL"},
+		{"Unknown", "10.1.2.3:1234", map[string]string{"CF-IPCountry": "XX", "X-Country-Code": "DE"}, "DE"},
+		{"Invalid", "10.1.2.3:1234", map[string]string{"CF-IPCountry": "Ukraine"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			if got := res.Country(req); got != tt.want {
+				t.Errorf("Country() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Package i18n — каталог повідомлень API українською та англійською
+// і вибір мови за заголовком Accept-Language.
+package i18n
+
+import (
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+// Lang — мова відповіді.
+type Lang string
+
+const (
+	Ukrainian Lang = "uk"
+	English   Lang = "en"
+
+	// Default використовується, якщо клієнт не вказав мову або жодна не підтримується.
+	Default = Ukrainian
+)
+
+// Порядок збігається з supported: перший тег — мова за замовчуванням для matcher.
+var (
+	supported = []Lang{Ukrainian, English}
+	matcher   = language.NewMatcher([]language.Tag{language.Ukrainian, language.English})
+)
+
+// Negotiate обирає мову за значенням Accept-Language з урахуванням q-ваг.
+func Negotiate(acceptLanguage string) Lang {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// FromRequest повертає мову, якою треба відповісти на запит.
+func FromRequest(r *http.Request) Lang {
+	if r == nil {
+		return Default
+	}
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Key — стабільний код повідомлення в каталозі.
+type Key string
+
+// RuleKey — ключ шаблону для правила валідації domain.Rule*.
+func RuleKey(rule string) Key {
+	return Key("rule." + rule)
+}
+
+// Lookup повертає текст повідомлення мовою lang.
+func Lookup(lang Lang, key Key) (string, bool) {
+	m, ok := messages[key]
+	if !ok {
+		return "", false
+	}
+	if lang == English {
+		return m.en, true
+	}
+	return m.uk, true
+}
+
+// T повертає текст повідомлення мовою lang, а для невідомого ключа — сам ключ,
+// щоб пропущений переклад було видно, а не отримати порожню відповідь.
+func T(lang Lang, key Key) string {
+	if text, ok := Lookup(lang, key); ok {
+		return text
+	}
+	return string(key)
+}
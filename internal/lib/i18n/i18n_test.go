@@ -0,0 +1,42 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Lang
+	}{
+		{"", Ukrainian},
+		{"en", English},
+		{"en-US,en;q=0.9", English},
+		{"uk-UA,uk;q=0.9,en;q=0.8", Ukrainian},
+		{"de-DE,en;q=0.5", English},
+		{"uk;q=0.2,en;q=0.8", English},
+		{"fr", Ukrainian},
+		{"*", Ukrainian},
+		{";;garbage", Ukrainian},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogComplete(t *testing.T) {
+	for key, m := range messages {
+		if strings.TrimSpace(m.uk) == "" || strings.TrimSpace(m.en) == "" {
+			t.Errorf("message %q is missing a translation: %+v", key, m)
+		}
+	}
+}
+
+func TestTUnknownKey(t *testing.T) {
+	if got := T(English, "no_such_key"); got != "no_such_key" {
+		t.Errorf("T(unknown) = %q, want the key itself", got)
+	}
+}
@@ -0,0 +1,181 @@
+package i18n
+
+// Ключі повідомлень. Значення — частина API: клієнти можуть покладатися на них так само, як на текст.
+const (
+	BadRequest       Key = "bad_request"
+	Unauthorized     Key = "unauthorized"
+	Forbidden        Key = "forbidden"
+	NotFound         Key = "not_found"
+	MethodNotAllowed Key = "method_not_allowed"
+	Conflict         Key = "conflict"
+	InternalError    Key = "internal_error"
+	RouteNotFound    Key = "route_not_found"
+
+	CheckFields      Key = "check_fields"
+	InvalidForm      Key = "invalid_form"
+	InvalidParameter Key = "invalid_parameter"
+	InvalidUserID    Key = "invalid_user_id"
+	InvalidTokenID   Key = "invalid_token_id"
+
+	InvalidCSRFToken       Key = "invalid_csrf_token"
+	StepUpRequired         Key = "step_up_required"
+	ImpersonationForbidden Key = "impersonation_forbidden"
+	InsufficientScope      Key = "insufficient_scope"
+
+	UserNotFound       Key = "user_not_found"
+	EmailTaken         Key = "email_taken"
+	LoginTaken         Key = "login_taken"
+	InvalidPassword    Key = "invalid_password"
+	InvalidCredentials Key = "invalid_credentials"
+	PasswordChanged    Key = "password_changed"
+	ProfileUpdated     Key = "profile_updated"
+
+	PasswordResetRequired Key = "password_reset_required"
+	PasswordResetSent     Key = "password_reset_sent"
+	InvalidLink           Key = "invalid_link"
+
+	AccountNotVerified Key = "account_not_verified"
+	AccountSuspended   Key = "account_suspended"
+	AccountDeactivated Key = "account_deactivated"
+	ReasonRequired     Key = "reason_required"
+	SuspendFailed      Key = "suspend_failed"
+	CannotSuspend      Key = "cannot_suspend"
+	UnsuspendFailed    Key = "unsuspend_failed"
+	UserSuspended      Key = "user_suspended"
+	UserUnsuspended    Key = "user_unsuspended"
+
+	CannotImpersonate           Key = "cannot_impersonate"
+	PasswordChangeImpersonation Key = "password_change_impersonation"
+
+	CookieSessionsDisabled  Key = "cookie_sessions_disabled"
+	LoggedOut               Key = "logged_out"
+	SessionNotFound         Key = "session_not_found"
+	SessionEndedCheckEmail  Key = "session_ended_check_email"
+	NotMeTitle              Key = "not_me_title"
+	NotMeConfirm            Key = "not_me_confirm"
+	NotMeSubmit             Key = "not_me_submit"
+	PersonalTokenNotAllowed Key = "personal_token_not_allowed"
+	ClientTokenNotAllowed   Key = "client_token_not_allowed"
+
+	TokenNotFound     Key = "token_not_found"
+	TokenRevoked      Key = "token_revoked"
+	TokenCreateFailed Key = "token_create_failed"
+
+	ProviderNotFound    Key = "provider_not_found"
+	ProviderLoginFailed Key = "provider_login_failed"
+	PartnerNotFound     Key = "partner_not_found"
+	SAMLLoginFailed     Key = "saml_login_failed"
+	LoginCancelled      Key = "login_cancelled"
+	IdentityNotFound    Key = "identity_not_found"
+	IdentityLinked      Key = "identity_linked"
+	IdentityUnlinked    Key = "identity_unlinked"
+
+	UnknownClient    Key = "unknown_client"
+	InvalidScope     Key = "invalid_scope"
+	AccessGranted    Key = "access_granted"
+	AccessNotGranted Key = "access_not_granted"
+	ConsentNotFound  Key = "consent_not_found"
+	ConsentRevoked   Key = "consent_revoked"
+
+	InvalidCode    Key = "invalid_code"
+	DeviceApproved Key = "device_approved"
+	RequestDenied  Key = "request_denied"
+)
+
+type message struct {
+	uk, en string
+}
+
+var messages = map[Key]message{
+	BadRequest:       {"Неправильний запит", "Bad request"},
+	Unauthorized:     {"Не авторизовано", "Unauthorized"},
+	Forbidden:        {"Доступ заборонено", "Access denied"},
+	NotFound:         {"Не знайдено", "Not found"},
+	MethodNotAllowed: {"Заборонений метод", "Method not allowed"},
+	Conflict:         {"Дані конфліктують з наявними", "The data conflicts with existing records"},
+	InternalError:    {"Помилка на сервері", "Internal server error"},
+	RouteNotFound:    {"Маршрут не знайдено", "Route not found"},
+
+	CheckFields:      {"Перевірте заповнення полів", "Please check the submitted fields"},
+	InvalidForm:      {"Помилка парсингу форми", "Could not parse the form"},
+	InvalidParameter: {"Неправильний параметр", "Invalid parameter"},
+	InvalidUserID:    {"Неправильний ідентифікатор користувача", "Invalid user ID"},
+	InvalidTokenID:   {"Неправильний ідентифікатор токена", "Invalid token ID"},
+
+	InvalidCSRFToken:       {"Недійсний CSRF-токен", "Invalid CSRF token"},
+	StepUpRequired:         {"Потрібна повторна автентифікація", "Re-authentication required"},
+	ImpersonationForbidden: {"Операція недоступна під час імперсонації", "This operation is not available while impersonating"},
+	InsufficientScope:      {"Токен не має потрібних дозволів", "The token lacks the required permissions"},
+
+	UserNotFound:       {"Користувача не знайдено", "User not found"},
+	EmailTaken:         {"Користувач з таким email вже існує", "A user with this email already exists"},
+	LoginTaken:         {"Користувач з таким логіном вже існує", "A user with this login already exists"},
+	InvalidPassword:    {"Неправильний пароль", "Incorrect password"},
+	InvalidCredentials: {"Неправильний email або пароль", "Incorrect email or password"},
+	PasswordChanged:    {"Пароль змінено", "Password changed"},
+	ProfileUpdated:     {"Профіль оновлено", "Profile updated"},
+
+	PasswordResetRequired: {"Потрібно скинути пароль", "Password reset required"},
+	PasswordResetSent:     {"Якщо обліковий запис існує, лист для скидання пароля надіслано", "If the account exists, a password reset email has been sent"},
+	InvalidLink:           {"Посилання недійсне або застаріле", "The link is invalid or expired"},
+
+	AccountNotVerified: {"Обліковий запис не підтверджено", "Account not verified"},
+	AccountSuspended:   {"Обліковий запис заблоковано", "Account suspended"},
+	AccountDeactivated: {"Обліковий запис деактивовано", "Account deactivated"},
+	ReasonRequired:     {"Потрібно вказати причину", "A reason is required"},
+	SuspendFailed:      {"Не вдалося заблокувати користувача", "Could not suspend the user"},
+	CannotSuspend:      {"Не можна заблокувати себе чи іншого адміністратора", "You cannot suspend yourself or another admin"},
+	UnsuspendFailed:    {"Не вдалося розблокувати користувача", "Could not unsuspend the user"},
+	UserSuspended:      {"Користувача заблоковано", "User suspended"},
+	UserUnsuspended:    {"Користувача розблоковано", "User unsuspended"},
+
+	CannotImpersonate:           {"Не можна діяти від імені цього користувача", "You cannot act on behalf of this user"},
+	PasswordChangeImpersonation: {"Зміна пароля недоступна під час імперсонації", "Password cannot be changed while impersonating"},
+
+	CookieSessionsDisabled:  {"Сесії в cookie вимкнено", "Cookie sessions are disabled"},
+	LoggedOut:               {"Сесію завершено", "Signed out"},
+	SessionNotFound:         {"Сесію не знайдено", "Session not found"},
+	SessionEndedCheckEmail:  {"Сесію завершено. Перевірте пошту, щоб встановити новий пароль", "Signed out. Check your email to set a new password"},
+	NotMeTitle:              {"Це були не ви?", "Wasn't you?"},
+	NotMeConfirm:            {"Сесію, з якої виконано вхід, буде завершено, а пароль потрібно буде змінити", "The session that signed in will be ended and you will have to set a new password"},
+	NotMeSubmit:             {"Це був не я", "This wasn't me"},
+	PersonalTokenNotAllowed: {"Дія недоступна для персонального токена", "This action is not available for personal access tokens"},
+	ClientTokenNotAllowed:   {"Дія недоступна для токена застосунку", "This action is not available for application tokens"},
+
+	TokenNotFound:     {"Токен не знайдено", "Token not found"},
+	TokenRevoked:      {"Токен відкликано", "Token revoked"},
+	TokenCreateFailed: {"Не вдалося створити токен", "Could not create the token"},
+
+	ProviderNotFound:    {"Провайдера не знайдено", "Provider not found"},
+	ProviderLoginFailed: {"Не вдалося увійти через провайдера", "Could not sign in with the provider"},
+	PartnerNotFound:     {"Партнера не знайдено", "Partner not found"},
+	SAMLLoginFailed:     {"Не вдалося увійти через корпоративний обліковий запис", "Could not sign in with the corporate account"},
+	LoginCancelled:      {"Вхід скасовано", "Sign-in cancelled"},
+	IdentityNotFound:    {"Прив'язку не знайдено", "Linked account not found"},
+	IdentityLinked:      {"Обліковий запис прив'язано", "Account linked"},
+	IdentityUnlinked:    {"Обліковий запис відв'язано", "Account unlinked"},
+
+	UnknownClient:    {"Невідомий застосунок", "Unknown application"},
+	InvalidScope:     {"Неправильний набір дозволів", "Invalid set of permissions"},
+	AccessGranted:    {"Доступ надано", "Access granted"},
+	AccessNotGranted: {"Доступ не надано", "Access not granted"},
+	ConsentNotFound:  {"Згоду не знайдено", "Consent not found"},
+	ConsentRevoked:   {"Доступ застосунку відкликано", "Application access revoked"},
+
+	InvalidCode:    {"Код недійсний або застарілий", "The code is invalid or expired"},
+	DeviceApproved: {"Пристрій підключено", "Device connected"},
+	RequestDenied:  {"Запит відхилено", "Request denied"},
+
+	// Шаблони помилок полів; ключі — RuleKey(domain.Rule*), параметри підставляє domain.FormatMessage.
+	"rule.required":           {"Поле обов'язкове", "This field is required"},
+	"rule.too_short":          {"Мінімальна довжина — {min} символів", "Must be at least {min} characters"},
+	"rule.too_long":           {"Максимальна довжина — {max} символів", "Must be at most {max} characters"},
+	"rule.invalid_email":      {"Неправильний формат email", "Invalid email address"},
+	"rule.invalid_phone":      {"Неправильний номер телефону, очікується формат +380XXXXXXXXX", "Invalid phone number, expected format +380XXXXXXXXX"},
+	"rule.invalid_characters": {"Поле містить недозволені символи", "Contains characters that are not allowed"},
+	"rule.unknown_value":      {"Невідоме значення {value}", "Unknown value {value}"},
+	"rule.not_in_future":      {"Дата має бути в майбутньому", "Must be a date in the future"},
+	"rule.not_member":         {"Користувач не належить до організації {org_id}", "The user is not a member of organization {org_id}"},
+	"rule.taken":              {"Значення вже зайняте", "This value is already taken"},
+	"rule.invalid":            {"Неправильне значення", "Invalid value"},
+}
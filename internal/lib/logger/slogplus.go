@@ -6,6 +6,7 @@ import (
 	"io"
 	stdLog "log"
 	"log/slog"
+	"sso-service/internal/lib/requestid"
 
 	"github.com/fatih/color"
 )
@@ -42,7 +43,7 @@ func (opts PlusHandlerOptions) NewPlusHandler(out io.Writer) *PlusHandler {
 	return h
 }
 
-func (h *PlusHandler) Handle(_ context.Context, rec slog.Record) error {
+func (h *PlusHandler) Handle(ctx context.Context, rec slog.Record) error {
 	level := rec.Level.String() + ":"
 
 	switch rec.Level {
@@ -68,6 +69,11 @@ func (h *PlusHandler) Handle(_ context.Context, rec slog.Record) error {
 		fields[a.Key] = a.Value.Any()
 	}
 
+	// request_id додається до кожного запису, зробленого з контекстом запиту (slog.*Context).
+	if id := requestid.FromContext(ctx); id != "" {
+		fields["request_id"] = id
+	}
+
 	var b []byte
 	var err error
 
@@ -0,0 +1,56 @@
+// Package requestid — ідентифікатор запиту, за яким відповідь клієнту зіставляється з журналами.
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header — заголовок, яким ідентифікатор приходить від шлюзу і повертається клієнту.
+const Header = "X-Request-ID"
+
+const maxLength = 128
+
+type contextKey struct{}
+
+// WithID кладе ідентифікатор запиту в контекст.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext повертає ідентифікатор запиту або порожній рядок.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// FromRequest повертає ідентифікатор з контексту, а якщо його там немає — з заголовка запиту.
+func FromRequest(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if id := FromContext(r.Context()); id != "" {
+		return id
+	}
+	if id := r.Header.Get(Header); Valid(id) {
+		return id
+	}
+	return ""
+}
+
+// Valid приймає лише короткі ідентифікатори з латиниці, цифр і "-_.:",
+// щоб чужий заголовок не зламав журнали чи відповіді.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
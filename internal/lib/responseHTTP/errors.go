@@ -0,0 +1,113 @@
+package responseHTTP
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+)
+
+// Машинозчитувані коди помилок. Клієнти розгалужуються за ними, тому значення не змінюються.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal_error"
+)
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          CodeBadRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusMethodNotAllowed:    CodeMethodNotAllowed,
+	http.StatusConflict:            CodeConflict,
+	http.StatusInternalServerError: CodeInternal,
+}
+
+// defaultMessages — повідомлення для користувача, коли обробник не передав власне.
+var defaultMessages = map[int]i18n.Key{
+	http.StatusBadRequest:          i18n.BadRequest,
+	http.StatusUnauthorized:        i18n.Unauthorized,
+	http.StatusForbidden:           i18n.Forbidden,
+	http.StatusNotFound:            i18n.NotFound,
+	http.StatusMethodNotAllowed:    i18n.MethodNotAllowed,
+	http.StatusConflict:            i18n.Conflict,
+	http.StatusInternalServerError: i18n.InternalError,
+}
+
+// ErrorCode повертає машинозчитуваний код для HTTP-статусу.
+func ErrorCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Classify визначає HTTP-статус і код помилки за її видом у domain.
+func Classify(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest, CodeValidation
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, CodeConflict
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized, CodeUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, CodeForbidden
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
+
+// Error відповідає на помилку сервісу або репозиторію. Статус і код визначає Classify,
+// message — повідомлення для користувача (порожнє замінюється типовим). Для невідомих помилок
+// повідомлення завжди типове, щоб не розкривати деталі.
+func Error(w http.ResponseWriter, r *http.Request, err error, message i18n.Key) {
+	status, code := Classify(err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "Помилка обробки запиту", "err", err.Error())
+		message = ""
+	}
+	if message == "" {
+		message = defaultMessages[status]
+	}
+
+	lang := i18n.FromRequest(r)
+	problem := newProblem(r, lang, status, code, message)
+
+	var validation *domain.ValidationError
+	var conflict *domain.FieldConflictError
+	switch {
+	case errors.As(err, &validation):
+		problem.Errors = localizeFields(lang, validation.Fields)
+	case errors.As(err, &conflict):
+		problem.Errors = localizeFields(lang, []domain.FieldError{domain.NewFieldError(conflict.Field, domain.RuleTaken, nil)})
+	}
+
+	writeProblem(w, r, lang, problem)
+}
+
+// localizeFields перекладає тексти помилок полів за їхніми кодами; поля без шаблону в каталозі
+// лишаються з текстом за замовчуванням.
+func localizeFields(lang i18n.Lang, fields []domain.FieldError) []domain.FieldError {
+	localized := make([]domain.FieldError, len(fields))
+	for i, field := range fields {
+		if template, ok := i18n.Lookup(lang, i18n.RuleKey(field.Code)); ok {
+			field.Message = domain.FormatMessage(template, field.Params)
+		}
+		localized[i] = field
+	}
+	return localized
+}
@@ -0,0 +1,142 @@
+package responseHTTP
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/requestid"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{domain.ErrUserNotFound, http.StatusNotFound, CodeNotFound},
+		{fmt.Errorf("get user: %w", domain.ErrUserNotFound), http.StatusNotFound, CodeNotFound},
+		{domain.ErrUserExists, http.StatusConflict, CodeConflict},
+		{domain.ErrInvalidPassword, http.StatusUnauthorized, CodeUnauthorized},
+		{domain.NewError(domain.ErrForbidden, "cannot impersonate yourself"), http.StatusForbidden, CodeForbidden},
+		{domain.NewValidationError("email", domain.RuleRequired, nil), http.StatusBadRequest, CodeValidation},
+		{errors.New("connection refused"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		status, code := Classify(tt.err)
+		if status != tt.status || code != tt.code {
+			t.Errorf("Classify(%v) = %d, %q; want %d, %q", tt.err, status, code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	decode := func(rec *httptest.ResponseRecorder) Problem {
+		t.Helper()
+		var resp Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v; body: %s", err, rec.Body.String())
+		}
+		return resp
+	}
+	request := func(acceptLanguage string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		return req
+	}
+	invalid := domain.NewValidationError("email", domain.RuleRequired, nil).Add("login", domain.RuleTooShort, domain.Params{"min": 3})
+
+	t.Run("Validation", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Error(rec, request(""), invalid, i18n.CheckFields)
+
+		resp := decode(rec)
+		if rec.Code != http.StatusBadRequest || resp.Code != CodeValidation || resp.Detail != "Перевірте заповнення полів" {
+			t.Errorf("response = %d %+v", rec.Code, resp)
+		}
+		if len(resp.Errors) != 2 || resp.Errors[1].Field != "login" || resp.Errors[1].Message != "Мінімальна довжина — 3 символів" {
+			t.Errorf("fields = %+v, want email and login", resp.Errors)
+		}
+	})
+
+	t.Run("English", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Error(rec, request("en-GB,en;q=0.9,uk;q=0.5"), invalid, i18n.CheckFields)
+
+		resp := decode(rec)
+		if resp.Detail != "Please check the submitted fields" || resp.Errors[1].Message != "Must be at least 3 characters" {
+			t.Errorf("response = %+v, want English messages", resp)
+		}
+		if lang := rec.Header().Get("Content-Language"); lang != "en" {
+			t.Errorf("Content-Language = %q, want en", lang)
+		}
+	})
+
+	t.Run("DefaultMessage", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Error(rec, request(""), domain.ErrSessionNotFound, "")
+
+		if resp := decode(rec); rec.Code != http.StatusNotFound || resp.Detail != "Не знайдено" {
+			t.Errorf("response = %d %+v", rec.Code, resp)
+		}
+	})
+
+	t.Run("InternalHidesMessage", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Error(rec, request(""), errors.New("pq: connection refused"), i18n.SessionNotFound)
+
+		resp := decode(rec)
+		if rec.Code != http.StatusInternalServerError || resp.Code != CodeInternal || resp.Detail != "Помилка на сервері" {
+			t.Errorf("response = %d %+v", rec.Code, resp)
+		}
+	})
+}
+
+func TestProblem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/sso/sessions/7", nil)
+	req.Header.Set(requestid.Header, "gw-42")
+	rec := httptest.NewRecorder()
+	Error(rec, req, domain.ErrSessionNotFound, i18n.SessionNotFound)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, ProblemContentType) {
+		t.Errorf("Content-Type = %q, want %s", ct, ProblemContentType)
+	}
+	if id := rec.Header().Get(requestid.Header); id != "gw-42" {
+		t.Errorf("%s = %q, want gw-42", requestid.Header, id)
+	}
+
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode: %v; body: %s", err, rec.Body.String())
+	}
+	want := Problem{
+		Type:      "urn:sso:problem:not_found",
+		Title:     "Не знайдено",
+		Status:    http.StatusNotFound,
+		Detail:    i18n.T(i18n.Default, i18n.SessionNotFound),
+		Instance:  "/api/sso/sessions/7",
+		Code:      CodeNotFound,
+		RequestID: "gw-42",
+	}
+	if !reflect.DeepEqual(problem, want) {
+		t.Errorf("problem = %+v, want %+v", problem, want)
+	}
+
+	t.Run("InvalidRequestID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "bad id\r\n")
+		rec := httptest.NewRecorder()
+		JSONError(rec, req, http.StatusBadRequest, "")
+
+		if id := rec.Header().Get(requestid.Header); id != "" {
+			t.Errorf("%s = %q, want it dropped", requestid.Header, id)
+		}
+	})
+}
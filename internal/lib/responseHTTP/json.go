@@ -4,22 +4,81 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/requestid"
 )
 
-type ErrorResponse struct {
+// ProblemContentType — тип відповіді з помилкою за RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix — type проблеми є URN з машинозчитуваним кодом, напр. "urn:sso:problem:not_found".
+const problemTypePrefix = "urn:sso:problem:"
+
+// Problem — тіло будь-якої помилки API (RFC 7807).
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code — машинозчитуваний код (див. Code* у errors.go), той самий, що в кінці Type.
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// MessageResponse — тіло успішної відповіді, що не повертає даних.
+type MessageResponse struct {
+	Status int `json:"status"`
+	// Code — стабільний ключ повідомлення, напр. "token_revoked".
+	Code    string `json:"code"`
 	Message string `json:"message"`
-	Code    int    `json:"code,omitempty"`
 }
 
-func JSONError(w http.ResponseWriter, code int, errMessage string) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+// JSONError відповідає помилкою з повідомленням з каталогу мовою клієнта (Accept-Language).
+func JSONError(w http.ResponseWriter, r *http.Request, code int, message i18n.Key) {
+	lang := i18n.FromRequest(r)
+	writeProblem(w, r, lang, newProblem(r, lang, code, ErrorCode(code), message))
+}
+
+// newProblem заповнює спільні поля проблеми: заголовок за статусом, опис з каталогу,
+// шлях запиту та його ідентифікатор.
+func newProblem(r *http.Request, lang i18n.Lang, status int, code string, message i18n.Key) Problem {
+	title := http.StatusText(status)
+	if key, ok := defaultMessages[status]; ok {
+		title = i18n.T(lang, key)
+	}
+
+	problem := Problem{
+		Type:      problemTypePrefix + code,
+		Title:     title,
+		Status:    status,
+		Code:      code,
+		RequestID: requestid.FromRequest(r),
+	}
+	if message != "" {
+		problem.Detail = i18n.T(lang, message)
+	}
+	if r != nil {
+		problem.Instance = r.URL.Path
+	}
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, lang i18n.Lang, problem Problem) {
+	setLanguage(w, lang)
+	if problem.RequestID != "" {
+		w.Header().Set(requestid.Header, problem.RequestID)
+	}
+	w.Header().Set("Content-Type", ProblemContentType+"; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
-	w.WriteHeader(code)
+	w.WriteHeader(problem.Status)
 
-	resp := ErrorResponse{Message: errMessage, Code: code}
-	err := json.NewEncoder(w).Encode(resp)
+	err := json.NewEncoder(w).Encode(problem)
 	if err != nil {
-		slog.Debug("Помилка у кодуванні JSONError:", "err", err.Error())
+		slog.Debug("Помилка у кодуванні Problem:", "err", err.Error())
 	}
 }
 
@@ -32,12 +91,20 @@ func JSONResp(w http.ResponseWriter, code int, payload any) {
 	}
 }
 
-func JSONRespMessage(w http.ResponseWriter, code int, message string) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(code)
-	resp := ErrorResponse{Message: message, Code: code}
-	err := json.NewEncoder(w).Encode(resp)
-	if err != nil {
-		slog.Debug("Помилка у кодуванні JSONRespMessage:", "err", err.Error())
-	}
+// JSONRespMessage відповідає повідомленням з каталогу мовою клієнта.
+func JSONRespMessage(w http.ResponseWriter, r *http.Request, code int, message i18n.Key) {
+	lang := i18n.FromRequest(r)
+	setLanguage(w, lang)
+	JSONResp(w, code, MessageResponse{Status: code, Code: string(message), Message: i18n.T(lang, message)})
+}
+
+// setLanguage позначає мову відповіді; Vary потрібен, щоб кеші не віддали відповідь іншою мовою.
+func setLanguage(w http.ResponseWriter, lang i18n.Lang) {
+	w.Header().Set("Content-Language", string(lang))
+	w.Header().Add("Vary", "Accept-Language")
+}
+
+// AuthError — auth.ErrorWriter для middleware з pkg/auth: коди auth.ErrCode* збігаються з ключами каталогу.
+func AuthError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	JSONError(w, r, status, i18n.Key(code))
 }
@@ -0,0 +1,13 @@
+-- Базова таблиця користувачів. IF NOT EXISTS — щоб прийняти бази, створені до появи міграцій.
+CREATE TABLE IF NOT EXISTS users (
+    user_id       SERIAL PRIMARY KEY,
+    login         TEXT NOT NULL UNIQUE,
+    hash_password TEXT NOT NULL,
+    role          TEXT NOT NULL DEFAULT 'user',
+    email         TEXT NOT NULL UNIQUE,
+    address       TEXT NOT NULL DEFAULT '',
+    phonenumber   TEXT NOT NULL DEFAULT '',
+    first_name    TEXT NOT NULL DEFAULT '',
+    last_name     TEXT NOT NULL DEFAULT '',
+    avatar_path   TEXT
+);
@@ -0,0 +1,15 @@
+-- actor_id і target_id без зовнішніх ключів: журнал має пережити видалення користувача.
+CREATE TABLE IF NOT EXISTS audit_events (
+    event_id   BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    actor_id   INTEGER,
+    target_id  INTEGER,
+    ip         TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    details    JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_created_idx ON audit_events (created_at DESC);
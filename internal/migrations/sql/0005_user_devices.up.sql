@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS user_devices (
+    user_id       INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    fingerprint   TEXT NOT NULL,
+    user_agent    TEXT NOT NULL DEFAULT '',
+    ip_prefix     TEXT NOT NULL DEFAULT '',
+    country       TEXT NOT NULL DEFAULT '',
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, fingerprint)
+);
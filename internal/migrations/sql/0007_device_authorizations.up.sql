@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS device_authorizations (
+    device_code_hash TEXT PRIMARY KEY,
+    user_code        TEXT NOT NULL UNIQUE,
+    client_id        TEXT NOT NULL,
+    scope            TEXT NOT NULL DEFAULT '',
+    status           TEXT NOT NULL DEFAULT 'pending',
+    user_id          INTEGER REFERENCES users (user_id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL,
+    expires_at       TIMESTAMPTZ NOT NULL,
+    last_polled_at   TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS users_login_lower_key;
+DROP INDEX IF EXISTS users_email_lower_key;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_email_key UNIQUE (email),
+    ADD CONSTRAINT users_login_key UNIQUE (login);
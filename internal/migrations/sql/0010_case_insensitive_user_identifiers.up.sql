@@ -0,0 +1,11 @@
+-- Email і логін унікальні без урахування регістру; застосунок зберігає їх нормалізованими.
+-- Якщо в базі вже є записи, що відрізняються лише регістром, міграція впаде на створенні
+-- індексу — такі облікові записи треба об'єднати вручну.
+UPDATE users SET email = LOWER(TRIM(email)), login = LOWER(TRIM(login));
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_email_key,
+    DROP CONSTRAINT IF EXISTS users_login_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (LOWER(email));
+CREATE UNIQUE INDEX IF NOT EXISTS users_login_lower_key ON users (LOWER(login));
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS used_tokens (
+    token_id   TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS used_tokens_expires_at_idx ON used_tokens (expires_at);
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"sso-service/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type PostgresAccessTokenRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresAccessTokenRepo(db *sql.DB) *PostgresAccessTokenRepo {
+	return &PostgresAccessTokenRepo{db: db}
+}
+
+func (r *PostgresAccessTokenRepo) CreateToken(ctx context.Context, token domain.PersonalAccessToken) (int, error) {
+	query := `INSERT INTO personal_access_tokens (user_id, org_id, name, prefix, token_hash, scopes, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING token_id`
+
+	var tokenID int
+	err := r.db.QueryRowContext(ctx, query, token.UserID, token.OrgID, token.Name, token.Prefix, token.TokenHash,
+		pq.Array(token.Scopes), token.ExpiresAt).Scan(&tokenID)
+	if err != nil {
+		slog.Debug("Помилка при створенні персонального токена", "err", err.Error())
+	}
+	return tokenID, err
+}
+
+const accessTokenColumns = `token_id, user_id, org_id, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at, revoked_at`
+
+func scanAccessToken(row interface{ Scan(...any) error }) (domain.PersonalAccessToken, error) {
+	var token domain.PersonalAccessToken
+	var orgID sql.NullInt64
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&token.ID, &token.UserID, &orgID, &token.Name, &token.Prefix, &token.TokenHash,
+		pq.Array(&token.Scopes), &expiresAt, &lastUsedAt, &token.CreatedAt, &revokedAt)
+	if err != nil {
+		return token, err
+	}
+
+	if orgID.Valid {
+		id := int(orgID.Int64)
+		token.OrgID = &id
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+func (r *PostgresAccessTokenRepo) GetTokenByHash(ctx context.Context, tokenHash string) (domain.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens WHERE token_hash = $1`
+
+	token, err := scanAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return token, domain.ErrAccessTokenNotFound
+	}
+	return token, err
+}
+
+func (r *PostgresAccessTokenRepo) ListTokens(ctx context.Context, userID int) ([]domain.PersonalAccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM personal_access_tokens
+	WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.Debug("Помилка при отриманні персональних токенів", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []domain.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *PostgresAccessTokenRepo) RevokeToken(ctx context.Context, userID, tokenID int) error {
+	query := `UPDATE personal_access_tokens SET revoked_at = NOW()
+	WHERE token_id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, tokenID, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresAccessTokenRepo) TouchToken(ctx context.Context, tokenID int, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = $2 WHERE token_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, tokenID, usedAt)
+	return err
+}
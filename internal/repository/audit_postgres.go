@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"sso-service/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type PostgresAuditRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresAuditRepo(db *sql.DB) *PostgresAuditRepo {
+	return &PostgresAuditRepo{db: db}
+}
+
+func (r *PostgresAuditRepo) Append(ctx context.Context, event domain.AuditEvent) error {
+	query := `INSERT INTO audit_events (event_type, actor_id, target_id, ip, user_agent, details)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, event.Type, event.ActorID, event.TargetID, event.IP, event.UserAgent, details)
+	if err != nil {
+		slog.Debug("Помилка при записі події аудиту", "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresAuditRepo) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	var conds []string
+	var args []any
+
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conds = append(conds, fmt.Sprintf("(actor_id = $%d OR target_id = $%d)", len(args), len(args)))
+	}
+	if filter.ActorID != nil {
+		addCond("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		addCond("target_id = $%d", *filter.TargetID)
+	}
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, t := range filter.Types {
+			types[i] = string(t)
+		}
+		addCond("event_type = ANY($%d)", pq.Array(types))
+	}
+	if filter.From != nil {
+		addCond("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCond("created_at < $%d", *filter.To)
+	}
+
+	query := `SELECT event_id, event_type, actor_id, target_id, ip, user_agent, details, created_at FROM audit_events`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, event_id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Debug("Помилка при отриманні подій аудиту", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		var event domain.AuditEvent
+		var actorID, targetID sql.NullInt64
+		var details []byte
+
+		err := rows.Scan(&event.ID, &event.Type, &actorID, &targetID, &event.IP, &event.UserAgent, &details, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			event.ActorID = &id
+		}
+		if targetID.Valid {
+			id := int(targetID.Int64)
+			event.TargetID = &id
+		}
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &event.Details); err != nil {
+				return nil, err
+			}
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
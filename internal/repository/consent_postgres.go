@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"sso-service/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type PostgresConsentRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresConsentRepo(db *sql.DB) *PostgresConsentRepo {
+	return &PostgresConsentRepo{db: db}
+}
+
+func (r *PostgresConsentRepo) GetConsent(ctx context.Context, userID int, clientID string) (domain.ConsentGrant, error) {
+	query := `SELECT user_id, client_id, scopes, created_at, updated_at FROM consent_grants
+	WHERE user_id = $1 AND client_id = $2`
+
+	var grant domain.ConsentGrant
+	err := r.db.QueryRowContext(ctx, query, userID, clientID).Scan(&grant.UserID, &grant.ClientID,
+		pq.Array(&grant.Scopes), &grant.CreatedAt, &grant.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return grant, domain.ErrConsentNotFound
+		}
+		return grant, err
+	}
+
+	return grant, nil
+}
+
+func (r *PostgresConsentRepo) SaveConsent(ctx context.Context, grant domain.ConsentGrant) error {
+	query := `INSERT INTO consent_grants (user_id, client_id, scopes, created_at, updated_at)
+	VALUES ($1, $2, $3, NOW(), NOW())
+	ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, updated_at = NOW()`
+
+	_, err := r.db.ExecContext(ctx, query, grant.UserID, grant.ClientID, pq.Array(grant.Scopes))
+	if err != nil {
+		slog.Debug("Помилка при збереженні згоди", "err", err.Error())
+	}
+	return err
+}
+
+func (r *PostgresConsentRepo) ListConsents(ctx context.Context, userID int) ([]domain.ConsentGrant, error) {
+	query := `SELECT user_id, client_id, scopes, created_at, updated_at FROM consent_grants
+	WHERE user_id = $1 ORDER BY updated_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.Debug("Помилка при отриманні згод", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []domain.ConsentGrant{}
+	for rows.Next() {
+		var grant domain.ConsentGrant
+		if err := rows.Scan(&grant.UserID, &grant.ClientID, pq.Array(&grant.Scopes), &grant.CreatedAt, &grant.UpdatedAt); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+func (r *PostgresConsentRepo) RevokeConsent(ctx context.Context, userID int, clientID string) error {
+	query := `DELETE FROM consent_grants WHERE user_id = $1 AND client_id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, userID, clientID)
+	if err != nil {
+		slog.Debug("Помилка при відкликанні згоди", "err", err.Error())
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrConsentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"sso-service/internal/domain"
+)
+
+type PostgresDeviceAuthRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresDeviceAuthRepo(db *sql.DB) *PostgresDeviceAuthRepo {
+	return &PostgresDeviceAuthRepo{db: db}
+}
+
+func (r *PostgresDeviceAuthRepo) CreateDeviceAuthorization(ctx context.Context, auth domain.DeviceAuthorization) error {
+	query := `INSERT INTO device_authorizations (device_code_hash, user_code, client_id, scope, status, interval_seconds, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := r.db.ExecContext(ctx, query, auth.DeviceCodeHash, auth.UserCode, auth.ClientID, auth.Scope, auth.Status,
+		int(auth.Interval.Seconds()), auth.ExpiresAt)
+	if err != nil {
+		slog.Debug("Помилка при створенні запиту пристрою", "err", err.Error())
+	}
+	return err
+}
+
+func (r *PostgresDeviceAuthRepo) getDeviceAuthorization(ctx context.Context, where string, arg any) (domain.DeviceAuthorization, error) {
+	query := `SELECT device_code_hash, user_code, client_id, scope, status, user_id, interval_seconds, expires_at, last_polled_at, created_at
+	FROM device_authorizations WHERE ` + where
+
+	var auth domain.DeviceAuthorization
+	var userID sql.NullInt64
+	var interval int
+	var lastPolled sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(&auth.DeviceCodeHash, &auth.UserCode, &auth.ClientID, &auth.Scope,
+		&auth.Status, &userID, &interval, &auth.ExpiresAt, &lastPolled, &auth.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return auth, domain.ErrDeviceAuthorizationNotFound
+		}
+		return auth, err
+	}
+
+	auth.Interval = time.Duration(interval) * time.Second
+	if userID.Valid {
+		id := int(userID.Int64)
+		auth.UserID = &id
+	}
+	if lastPolled.Valid {
+		auth.LastPolledAt = &lastPolled.Time
+	}
+
+	return auth, nil
+}
+
+func (r *PostgresDeviceAuthRepo) GetByDeviceCode(ctx context.Context, deviceCodeHash string) (domain.DeviceAuthorization, error) {
+	return r.getDeviceAuthorization(ctx, "device_code_hash = $1", deviceCodeHash)
+}
+
+func (r *PostgresDeviceAuthRepo) GetByUserCode(ctx context.Context, userCode string) (domain.DeviceAuthorization, error) {
+	return r.getDeviceAuthorization(ctx, "user_code = $1", userCode)
+}
+
+func (r *PostgresDeviceAuthRepo) DecideDeviceAuthorization(ctx context.Context, userCode string, status domain.DeviceAuthorizationStatus, userID int) error {
+	query := `UPDATE device_authorizations SET status = $2, user_id = $3
+	WHERE user_code = $1 AND status = 'pending' AND expires_at > NOW()`
+
+	res, err := r.db.ExecContext(ctx, query, userCode, status, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrDeviceAuthorizationNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresDeviceAuthRepo) RecordDevicePoll(ctx context.Context, deviceCodeHash string, polledAt time.Time, interval time.Duration) error {
+	query := `UPDATE device_authorizations SET last_polled_at = $2, interval_seconds = $3 WHERE device_code_hash = $1`
+
+	_, err := r.db.ExecContext(ctx, query, deviceCodeHash, polledAt, int(interval.Seconds()))
+	return err
+}
+
+func (r *PostgresDeviceAuthRepo) ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) (bool, error) {
+	query := `UPDATE device_authorizations SET status = 'consumed' WHERE device_code_hash = $1 AND status = 'approved'`
+
+	res, err := r.db.ExecContext(ctx, query, deviceCodeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
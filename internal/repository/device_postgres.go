@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"sso-service/internal/domain"
+)
+
+type PostgresDeviceRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresDeviceRepo(db *sql.DB) *PostgresDeviceRepo {
+	return &PostgresDeviceRepo{db: db}
+}
+
+func (r *PostgresDeviceRepo) CountDevices(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM user_devices WHERE user_id = $1`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresDeviceRepo) IsKnownDevice(ctx context.Context, userID int, fingerprint string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_devices WHERE user_id = $1 AND fingerprint = $2)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, userID, fingerprint).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresDeviceRepo) IsKnownCountry(ctx context.Context, userID int, country string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_devices WHERE user_id = $1 AND country = $2)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, userID, country).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresDeviceRepo) RememberDevice(ctx context.Context, device domain.KnownDevice) error {
+	query := `INSERT INTO user_devices (user_id, fingerprint, user_agent, ip_prefix, country, first_seen_at, last_seen_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $6)
+	ON CONFLICT (user_id, fingerprint) DO UPDATE SET
+		last_seen_at = EXCLUDED.last_seen_at,
+		country = COALESCE(NULLIF(EXCLUDED.country, ''), user_devices.country)`
+
+	_, err := r.db.ExecContext(ctx, query, device.UserID, device.Fingerprint, device.UserAgent, device.IPPrefix,
+		device.Country, device.LastSeenAt)
+	if err != nil {
+		slog.Debug("Помилка при збереженні пристрою", "err", err.Error())
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation — код помилки Postgres для порушення UNIQUE.
+const uniqueViolation = "23505"
+
+// violatedUnique повертає назву порушеного UNIQUE-обмеження або індексу.
+func violatedUnique(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
+
+// conflictError замінює порушення UNIQUE на доменну помилку conflict, решту повертає як є.
+func conflictError(err error, conflict error) error {
+	if _, ok := violatedUnique(err); ok {
+		return conflict
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"sso-service/internal/domain"
+)
+
+type PostgresIdentityRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresIdentityRepo(db *sql.DB) *PostgresIdentityRepo {
+	return &PostgresIdentityRepo{db: db}
+}
+
+func (r *PostgresIdentityRepo) GetIdentity(ctx context.Context, provider, subject string) (domain.UserIdentity, error) {
+	query := `SELECT user_id, provider, subject, email, created_at FROM user_identities
+	WHERE provider = $1 AND subject = $2`
+
+	var identity domain.UserIdentity
+	err := r.db.QueryRowContext(ctx, query, provider, subject).Scan(&identity.UserID, &identity.Provider,
+		&identity.Subject, &identity.Email, &identity.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return identity, domain.ErrIdentityNotFound
+		}
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+func (r *PostgresIdentityRepo) ListIdentities(ctx context.Context, userID int) ([]domain.UserIdentity, error) {
+	query := `SELECT user_id, provider, subject, email, created_at FROM user_identities
+	WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.Debug("Помилка при отриманні зовнішніх облікових записів", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []domain.UserIdentity{}
+	for rows.Next() {
+		var identity domain.UserIdentity
+		if err := rows.Scan(&identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *PostgresIdentityRepo) LinkIdentity(ctx context.Context, identity domain.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		slog.Debug("Помилка при прив'язці зовнішнього облікового запису", "err", err.Error())
+	}
+	return conflictError(err, domain.ErrIdentityExists)
+}
+
+func (r *PostgresIdentityRepo) UnlinkIdentity(ctx context.Context, userID int, provider string) error {
+	query := `DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`
+
+	res, err := r.db.ExecContext(ctx, query, userID, provider)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrIdentityNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"sso-service/internal/domain"
+)
+
+type PostgresOrganizationRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresOrganizationRepo(db *sql.DB) *PostgresOrganizationRepo {
+	return &PostgresOrganizationRepo{db: db}
+}
+
+func (r *PostgresOrganizationRepo) GetOrganization(ctx context.Context, orgID int) (domain.Organization, error) {
+	query := `SELECT org_id, slug, name, created_at FROM organizations WHERE org_id = $1`
+
+	var org domain.Organization
+	err := r.db.QueryRowContext(ctx, query, orgID).Scan(&org.OrgID, &org.Slug, &org.Name, &org.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return org, domain.ErrOrganizationNotFound
+		}
+		return org, err
+	}
+
+	return org, nil
+}
+
+func (r *PostgresOrganizationRepo) EnsureOrganization(ctx context.Context, slug, name string) (domain.Organization, error) {
+	query := `INSERT INTO organizations (slug, name) VALUES ($1, $2)
+	ON CONFLICT (slug) DO UPDATE SET name = organizations.name
+	RETURNING org_id, slug, name, created_at`
+
+	var org domain.Organization
+	err := r.db.QueryRowContext(ctx, query, slug, name).Scan(&org.OrgID, &org.Slug, &org.Name, &org.CreatedAt)
+	if err != nil {
+		slog.Debug("Помилка при створенні організації", "err", err.Error())
+	}
+	return org, err
+}
@@ -0,0 +1,307 @@
+// Package repotest містить спільні тести контракту репозиторіїв,
+// які мають проходити всі реалізації: Postgres і в пам'яті.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sso-service/internal/domain"
+)
+
+// UserRepositoryHarness створює порожнє сховище для кожного підтесту.
+type UserRepositoryHarness struct {
+	New func(t *testing.T) domain.UserRepository
+	// Organization повертає ID наявної організації для SetOrganization.
+	Organization func(t *testing.T) int
+}
+
+func newUser(n int) domain.User {
+	return domain.User{
+		Login:        fmt.Sprintf("driver%d", n),
+		Email:        fmt.Sprintf("driver%d@carvia.test", n),
+		Role:         "user",
+		HashPassword: "hash",
+		FirstName:    "Олена",
+		LastName:     "Коваль",
+		Phonenumber:  "+380500000000",
+		Address:      "Київ",
+	}
+}
+
+func mustCreate(t *testing.T, repo domain.UserRepository, user domain.User) int {
+	t.Helper()
+	id, err := repo.CreateUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return id
+}
+
+// TestUserRepository перевіряє семантику domain.UserRepository.
+func TestUserRepository(t *testing.T, h UserRepositoryHarness) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := h.New(t)
+		user := newUser(1)
+		id := mustCreate(t, repo, user)
+		if id <= 0 {
+			t.Fatalf("CreateUser returned id %d", id)
+		}
+
+		for name, get := range map[string]func() (domain.User, error){
+			"GetByID":       func() (domain.User, error) { return repo.GetByID(ctx, id) },
+			"GetByEmail":    func() (domain.User, error) { return repo.GetByEmail(ctx, user.Email) },
+			"GetByUsername": func() (domain.User, error) { return repo.GetByUsername(ctx, user.Login) },
+		} {
+			got, err := get()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got.UserID != id || got.Login != user.Login || got.Email != user.Email || got.HashPassword != user.HashPassword ||
+				got.Role != user.Role || got.FirstName != user.FirstName || got.Address != user.Address {
+				t.Errorf("%s = %+v, want fields of %+v", name, got, user)
+			}
+			if got.Status != domain.UserStatusActive {
+				t.Errorf("%s: default status = %q, want %q", name, got.Status, domain.UserStatusActive)
+			}
+		}
+
+		second := mustCreate(t, repo, newUser(2))
+		if second == id {
+			t.Errorf("second user got the same id %d", id)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := h.New(t)
+		if _, err := repo.GetByID(ctx, 424242); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("GetByID: err = %v, want ErrUserNotFound", err)
+		}
+		if _, err := repo.GetByEmail(ctx, "nobody@carvia.test"); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("GetByEmail: err = %v, want ErrUserNotFound", err)
+		}
+		if _, err := repo.GetByUsername(ctx, "nobody"); !errors.Is(err, domain.ErrUserNotFound) {
+			t.Errorf("GetByUsername: err = %v, want ErrUserNotFound", err)
+		}
+
+		updates := map[string]error{
+			"UpdateUserProfile":        repo.UpdateUserProfile(ctx, domain.UserUpdateRequest{UserID: 424242, Login: "x", Email: "x@carvia.test"}),
+			"UpdateUserStatus":         repo.UpdateUserStatus(ctx, 424242, domain.UserStatusSuspended, "", nil),
+			"SetPasswordResetRequired": repo.SetPasswordResetRequired(ctx, 424242, true),
+			"UpdatePassword":           repo.UpdatePassword(ctx, 424242, "hash"),
+		}
+		for name, err := range updates {
+			if !errors.Is(err, domain.ErrUserNotFound) {
+				t.Errorf("%s: err = %v, want ErrUserNotFound", name, err)
+			}
+		}
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		repo := h.New(t)
+		user := newUser(1)
+		mustCreate(t, repo, user)
+
+		cases := []struct {
+			name string
+			fn   func(context.Context, string) (bool, error)
+			arg  string
+			want bool
+		}{
+			{"ExistsByEmail", repo.ExistsByEmail, user.Email, true},
+			{"ExistsByEmail", repo.ExistsByEmail, "other@carvia.test", false},
+			{"ExistsByUsername", repo.ExistsByUsername, user.Login, true},
+			{"ExistsByUsername", repo.ExistsByUsername, "other", false},
+		}
+		for _, c := range cases {
+			got, err := c.fn(ctx, c.arg)
+			if err != nil {
+				t.Fatalf("%s(%q): %v", c.name, c.arg, err)
+			}
+			if got != c.want {
+				t.Errorf("%s(%q) = %v, want %v", c.name, c.arg, got, c.want)
+			}
+		}
+	})
+
+	t.Run("UniqueLoginAndEmail", func(t *testing.T) {
+		repo := h.New(t)
+		mustCreate(t, repo, newUser(1))
+
+		sameEmail := newUser(2)
+		sameEmail.Email = newUser(1).Email
+		if _, err := repo.CreateUser(ctx, sameEmail); !errors.Is(err, domain.ErrEmailTaken) || !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("duplicate email: err = %v, want ErrEmailTaken", err)
+		}
+
+		sameLogin := newUser(3)
+		sameLogin.Login = newUser(1).Login
+		if _, err := repo.CreateUser(ctx, sameLogin); !errors.Is(err, domain.ErrLoginTaken) {
+			t.Errorf("duplicate login: err = %v, want ErrLoginTaken", err)
+		}
+
+		other := mustCreate(t, repo, newUser(4))
+		err := repo.UpdateUserProfile(ctx, domain.UserUpdateRequest{UserID: other, Login: "driver4", Email: newUser(1).Email})
+		if !errors.Is(err, domain.ErrEmailTaken) {
+			t.Errorf("update to taken email: err = %v, want ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("CaseInsensitive", func(t *testing.T) {
+		repo := h.New(t)
+		id := mustCreate(t, repo, newUser(1))
+
+		upper := newUser(2)
+		upper.Email = strings.ToUpper(newUser(1).Email)
+		if _, err := repo.CreateUser(ctx, upper); !errors.Is(err, domain.ErrEmailTaken) {
+			t.Errorf("email differing in case: err = %v, want ErrEmailTaken", err)
+		}
+
+		upper = newUser(3)
+		upper.Login = strings.ToUpper(newUser(1).Login)
+		if _, err := repo.CreateUser(ctx, upper); !errors.Is(err, domain.ErrLoginTaken) {
+			t.Errorf("login differing in case: err = %v, want ErrLoginTaken", err)
+		}
+
+		got, err := repo.GetByEmail(ctx, strings.ToUpper(newUser(1).Email))
+		if err != nil || got.UserID != id {
+			t.Errorf("GetByEmail in upper case = %d, %v; want user %d", got.UserID, err, id)
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		repo := h.New(t)
+
+		const workers = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user := newUser(100 + i)
+				user.Email = "race@carvia.test"
+				_, err := repo.CreateUser(ctx, user)
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, domain.ErrUserExists):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		if created != 1 {
+			t.Errorf("created %d users with the same email, want 1", created)
+		}
+	})
+
+	t.Run("UpdateUserProfile", func(t *testing.T) {
+		repo := h.New(t)
+		id := mustCreate(t, repo, newUser(1))
+
+		update := domain.UserUpdateRequest{
+			UserID:       id,
+			Login:        "renamed",
+			HashPassword: "new-hash",
+			Email:        "renamed@carvia.test",
+			FirstName:    "Ірина",
+			LastName:     "Шевчук",
+			Phonenumber:  "+380670000000",
+			Address:      "Львів",
+			AvatarPath:   "avatars/1.png",
+		}
+		if err := repo.UpdateUserProfile(ctx, update); err != nil {
+			t.Fatalf("UpdateUserProfile: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Login != update.Login || got.Email != update.Email || got.HashPassword != update.HashPassword ||
+			got.FirstName != update.FirstName || got.Address != update.Address || got.AvatarPath != update.AvatarPath {
+			t.Errorf("after update got %+v, want fields of %+v", got, update)
+		}
+
+		// Порожній AvatarPath не стирає наявний аватар.
+		update.AvatarPath = ""
+		if err := repo.UpdateUserProfile(ctx, update); err != nil {
+			t.Fatalf("UpdateUserProfile: %v", err)
+		}
+		got, _ = repo.GetByID(ctx, id)
+		if got.AvatarPath != "avatars/1.png" {
+			t.Errorf("AvatarPath = %q, want it kept", got.AvatarPath)
+		}
+	})
+
+	t.Run("UpdateUserStatus", func(t *testing.T) {
+		repo := h.New(t)
+		id := mustCreate(t, repo, newUser(1))
+
+		until := time.Now().Add(time.Hour).Truncate(time.Second)
+		if err := repo.UpdateUserStatus(ctx, id, domain.UserStatusSuspended, "spam", &until); err != nil {
+			t.Fatalf("UpdateUserStatus: %v", err)
+		}
+		got, _ := repo.GetByID(ctx, id)
+		if got.Status != domain.UserStatusSuspended || got.StatusReason != "spam" || got.StatusUntil == nil || !got.StatusUntil.Equal(until) {
+			t.Errorf("after suspend got status=%q reason=%q until=%v", got.Status, got.StatusReason, got.StatusUntil)
+		}
+
+		if err := repo.UpdateUserStatus(ctx, id, domain.UserStatusActive, "", nil); err != nil {
+			t.Fatalf("UpdateUserStatus: %v", err)
+		}
+		got, _ = repo.GetByID(ctx, id)
+		if got.Status != domain.UserStatusActive || got.StatusReason != "" || got.StatusUntil != nil {
+			t.Errorf("after activate got status=%q reason=%q until=%v", got.Status, got.StatusReason, got.StatusUntil)
+		}
+	})
+
+	t.Run("PasswordReset", func(t *testing.T) {
+		repo := h.New(t)
+		id := mustCreate(t, repo, newUser(1))
+
+		if err := repo.SetPasswordResetRequired(ctx, id, true); err != nil {
+			t.Fatalf("SetPasswordResetRequired: %v", err)
+		}
+		got, _ := repo.GetByID(ctx, id)
+		if !got.PasswordResetRequired {
+			t.Fatal("PasswordResetRequired not set")
+		}
+
+		if err := repo.UpdatePassword(ctx, id, "new-hash"); err != nil {
+			t.Fatalf("UpdatePassword: %v", err)
+		}
+		got, _ = repo.GetByID(ctx, id)
+		if got.HashPassword != "new-hash" || got.PasswordResetRequired {
+			t.Errorf("after UpdatePassword hash=%q reset_required=%v", got.HashPassword, got.PasswordResetRequired)
+		}
+	})
+
+	t.Run("SetOrganization", func(t *testing.T) {
+		repo := h.New(t)
+		id := mustCreate(t, repo, newUser(1))
+		orgID := h.Organization(t)
+
+		if err := repo.SetOrganization(ctx, id, orgID); err != nil {
+			t.Fatalf("SetOrganization: %v", err)
+		}
+		got, _ := repo.GetByID(ctx, id)
+		if got.OrgID == nil || *got.OrgID != orgID {
+			t.Errorf("OrgID = %v, want %d", got.OrgID, orgID)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"sso-service/internal/domain"
+)
+
+type PostgresSessionRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionRepo(db *sql.DB) *PostgresSessionRepo {
+	return &PostgresSessionRepo{db: db}
+}
+
+func (r *PostgresSessionRepo) CreateSession(ctx context.Context, session domain.Session) error {
+	query := `INSERT INTO sessions (session_id, user_id, device_name, user_agent, ip, created_at, last_seen_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := r.db.ExecContext(ctx, query, session.ID, session.UserID, session.DeviceName, session.UserAgent,
+		session.IP, session.CreatedAt, session.LastSeenAt)
+	if err != nil {
+		slog.Debug("Помилка при створенні сесії", "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresSessionRepo) GetSession(ctx context.Context, sessionID string) (domain.Session, error) {
+	query := `SELECT session_id, user_id, device_name, user_agent, ip, created_at, last_seen_at, revoked_at
+	FROM sessions WHERE session_id = $1`
+
+	var session domain.Session
+	var revokedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, sessionID).Scan(&session.ID, &session.UserID, &session.DeviceName,
+		&session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return session, domain.ErrSessionNotFound
+		}
+		return session, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
+
+func (r *PostgresSessionRepo) ListActiveSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	query := `SELECT session_id, user_id, device_name, user_agent, ip, created_at, last_seen_at
+	FROM sessions WHERE user_id = $1 AND revoked_at IS NULL
+	ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.Debug("Помилка при отриманні сесій", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []domain.Session{}
+	for rows.Next() {
+		var session domain.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.DeviceName, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *PostgresSessionRepo) TouchSession(ctx context.Context, sessionID string, ip, userAgent string, seenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2,
+		ip = COALESCE(NULLIF($3, ''), ip),
+		user_agent = COALESCE(NULLIF($4, ''), user_agent)
+	WHERE session_id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, sessionID, seenAt, ip, userAgent)
+	return err
+}
+
+func (r *PostgresSessionRepo) RevokeSession(ctx context.Context, sessionID string) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE session_id = $1 AND revoked_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, sessionID)
+	if err != nil {
+		slog.Debug("Помилка при відкликанні сесії", "err", err.Error())
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresSessionRepo) RevokeUserSessions(ctx context.Context, userID int) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		slog.Debug("Помилка при відкликанні сесій користувача", "err", err.Error())
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+type PostgresUsedTokenRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresUsedTokenRepo(db *sql.DB) *PostgresUsedTokenRepo {
+	return &PostgresUsedTokenRepo{db: db}
+}
+
+func (r *PostgresUsedTokenRepo) MarkUsed(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	// Прострочені записи вже нічого не захищають.
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM used_tokens WHERE expires_at < NOW()`); err != nil {
+		slog.Debug("Помилка при очищенні використаних токенів", "err", err.Error())
+	}
+
+	query := `INSERT INTO used_tokens (token_id, expires_at) VALUES ($1, $2) ON CONFLICT (token_id) DO NOTHING`
+
+	res, err := r.db.ExecContext(ctx, query, tokenID, expiresAt)
+	if err != nil {
+		slog.Debug("Помилка при збереженні використаного токена", "err", err.Error())
+		return false, err
+	}
+	inserted, err := res.RowsAffected()
+	return inserted == 1, err
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"sso-service/internal/domain"
+)
+
+// MemoryUserRepo — потокобезпечне сховище користувачів у пам'яті для тестів і локальних експериментів.
+// Поводиться як PostgresUserRepo: логін і email унікальні без урахування регістру, ті самі помилки.
+type MemoryUserRepo struct {
+	mu     sync.RWMutex
+	nextID int
+	users  map[int]domain.User
+}
+
+func NewMemoryUserRepo() *MemoryUserRepo {
+	return &MemoryUserRepo{users: make(map[int]domain.User)}
+}
+
+// conflict перевіряє унікальність email і логіна серед інших користувачів
+// і повертає помилку з назвою зайнятого поля. Викликається під mu.
+func (r *MemoryUserRepo) conflict(userID int, login, email string) error {
+	for id, user := range r.users {
+		if id == userID {
+			continue
+		}
+		if strings.EqualFold(user.Email, email) {
+			return domain.ErrEmailTaken
+		}
+		if strings.EqualFold(user.Login, login) {
+			return domain.ErrLoginTaken
+		}
+	}
+	return nil
+}
+
+// find повертає першого користувача, для якого match істинний. Викликається під mu.
+func (r *MemoryUserRepo) find(match func(domain.User) bool) (domain.User, bool) {
+	for _, user := range r.users {
+		if match(user) {
+			return copyUser(user), true
+		}
+	}
+	return domain.User{}, false
+}
+
+// copyUser не дає викликачу змінити збережені вказівники.
+func copyUser(user domain.User) domain.User {
+	if user.StatusUntil != nil {
+		until := *user.StatusUntil
+		user.StatusUntil = &until
+	}
+	if user.OrgID != nil {
+		orgID := *user.OrgID
+		user.OrgID = &orgID
+	}
+	return user
+}
+
+func (r *MemoryUserRepo) CreateUser(ctx context.Context, user domain.User) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.conflict(0, user.Login, user.Email); err != nil {
+		return 0, err
+	}
+
+	if user.Status == "" {
+		user.Status = domain.UserStatusActive
+	}
+	r.nextID++
+	user.UserID = r.nextID
+	r.users[user.UserID] = copyUser(user)
+
+	return user.UserID, nil
+}
+
+func (r *MemoryUserRepo) GetByID(ctx context.Context, userID int) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (r *MemoryUserRepo) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.find(func(u domain.User) bool { return strings.EqualFold(u.Login, username) })
+	if !ok {
+		return user, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUserRepo) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.find(func(u domain.User) bool { return strings.EqualFold(u.Email, email) })
+	if !ok {
+		return user, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *MemoryUserRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	_, err := r.GetByUsername(ctx, username)
+	return err == nil, nil
+}
+
+// update змінює користувача під блокуванням запису.
+func (r *MemoryUserRepo) update(userID int, fn func(user *domain.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	if err := fn(&user); err != nil {
+		return err
+	}
+	r.users[userID] = copyUser(user)
+	return nil
+}
+
+func (r *MemoryUserRepo) UpdateUserProfile(ctx context.Context, userData domain.UserUpdateRequest) error {
+	return r.update(userData.UserID, func(user *domain.User) error {
+		if err := r.conflict(userData.UserID, userData.Login, userData.Email); err != nil {
+			return err
+		}
+
+		user.Login = userData.Login
+		user.HashPassword = userData.HashPassword
+		user.FirstName = userData.FirstName
+		user.LastName = userData.LastName
+		user.Email = userData.Email
+		user.Phonenumber = userData.Phonenumber
+		user.Address = userData.Address
+		if userData.AvatarPath != "" {
+			user.AvatarPath = userData.AvatarPath
+		}
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) UpdateUserStatus(ctx context.Context, userID int, status domain.UserStatus, reason string, until *time.Time) error {
+	return r.update(userID, func(user *domain.User) error {
+		user.Status = status
+		user.StatusReason = reason
+		user.StatusUntil = until
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) SetPasswordResetRequired(ctx context.Context, userID int, required bool) error {
+	return r.update(userID, func(user *domain.User) error {
+		user.PasswordResetRequired = required
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) UpdatePassword(ctx context.Context, userID int, hashPassword string) error {
+	return r.update(userID, func(user *domain.User) error {
+		user.HashPassword = hashPassword
+		user.PasswordResetRequired = false
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) SetOrganization(ctx context.Context, userID, orgID int) error {
+	return r.update(userID, func(user *domain.User) error {
+		user.OrgID = &orgID
+		return nil
+	})
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"testing"
+
+	"sso-service/internal/domain"
+	"sso-service/internal/repository/repotest"
+)
+
+func TestMemoryUserRepo(t *testing.T) {
+	repotest.TestUserRepository(t, repotest.UserRepositoryHarness{
+		New: func(t *testing.T) domain.UserRepository {
+			return NewMemoryUserRepo()
+		},
+		Organization: func(t *testing.T) int {
+			return 7
+		},
+	})
+}
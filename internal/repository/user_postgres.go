@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"sso-service/internal/domain"
 )
@@ -17,73 +18,102 @@ func NewPostgresUserRepo(db *sql.DB) *PostgresUserRepo {
 	return &PostgresUserRepo{db: db}
 }
 
+// userAffected перетворює оновлення без змінених рядків на ErrUserNotFound.
+func userAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// userConflicts — унікальні індекси users (міграція 0010) і поле, яке кожен захищає.
+var userConflicts = map[string]error{
+	"users_email_lower_key": domain.ErrEmailTaken,
+	"users_login_lower_key": domain.ErrLoginTaken,
+}
+
+// userWriteError перетворює порушення унікальності email чи логіна на доменну помилку з назвою поля.
+// Саме вставка чи оновлення виявляє конфлікт, тож паралельні реєстрації не проходять обидві.
+func userWriteError(err error) error {
+	constraint, ok := violatedUnique(err)
+	if !ok {
+		return err
+	}
+	if conflict, ok := userConflicts[constraint]; ok {
+		return conflict
+	}
+	return domain.ErrUserExists
+}
+
 func (r *PostgresUserRepo) CreateUser(ctx context.Context, user domain.User) (int, error) {
-	query := `INSERT INTO users (login, hash_password, role, email, address, phonenumber, first_name, last_name ) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	query := `INSERT INTO users (login, hash_password, role, email, address, phonenumber, first_name, last_name, status, org_id) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	RETURNING user_id`
 
+	if user.Status == "" {
+		user.Status = domain.UserStatusActive
+	}
+
 	var userID int
 
-	err := r.db.QueryRowContext(ctx, query, user.Login, user.HashPassword, user.Role, user.Email, user.Address, user.Phonenumber, user.FirstName, user.LastName).Scan(&userID)
-	return userID, err
+	err := r.db.QueryRowContext(ctx, query, user.Login, user.HashPassword, user.Role, user.Email, user.Address, user.Phonenumber, user.FirstName, user.LastName, user.Status, user.OrgID).Scan(&userID)
+	return userID, userWriteError(err)
 }
 
-func (r *PostgresUserRepo) GetByUsername(ctx context.Context, username string) (domain.User, error) {
-	query := `SELECT user_id, login, hash_password, role, email, address, phonenumber, first_name, last_name, avatar_path
-	FROM users WHERE login = $1`
+const userColumns = `user_id, login, hash_password, role, email, address, phonenumber, first_name, last_name, avatar_path,
+	status, status_reason, status_until, password_reset_required, org_id`
+
+func (r *PostgresUserRepo) getUser(ctx context.Context, where string, arg any) (domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + where
 
 	var user domain.User
-	var avatar sql.NullString
+	var avatar, reason sql.NullString
+	var until sql.NullTime
+	var orgID sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.UserID, &user.Login, &user.HashPassword, &user.Role,
-		&user.Email, &user.Address, &user.Phonenumber, &user.FirstName, &user.LastName, &avatar)
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(&user.UserID, &user.Login, &user.HashPassword, &user.Role,
+		&user.Email, &user.Address, &user.Phonenumber, &user.FirstName, &user.LastName, &avatar,
+		&user.Status, &reason, &until, &user.PasswordResetRequired, &orgID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("user not found")
+			return user, domain.ErrUserNotFound
 		}
 		slog.Debug("Помилка при отриманні користувача з БД", "err", err.Error())
 		return user, err
 	}
 
-	if avatar.Valid {
-		user.AvatarPath = avatar.String
-	} else {
-		user.AvatarPath = ""
+	user.AvatarPath = avatar.String
+	user.StatusReason = reason.String
+	if until.Valid {
+		user.StatusUntil = &until.Time
+	}
+	if orgID.Valid {
+		id := int(orgID.Int64)
+		user.OrgID = &id
 	}
 
 	return user, nil
 }
 
-func (r *PostgresUserRepo) GetByEmail(ctx context.Context, email string) (domain.User, error) {
-	query := `SELECT user_id, login, hash_password, role, email, address, phonenumber, first_name, last_name, avatar_path
-	FROM users WHERE email = $1`
-
-	var user domain.User
-	var avatar sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&user.UserID, &user.Login, &user.HashPassword, &user.Role,
-		&user.Email, &user.Address, &user.Phonenumber, &user.FirstName, &user.LastName, &avatar)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return user, fmt.Errorf("user not found")
-		}
-		slog.Debug("Помилка при отриманні користувача з БД", "err", err.Error())
-		return user, err
-	}
+func (r *PostgresUserRepo) GetByID(ctx context.Context, userID int) (domain.User, error) {
+	return r.getUser(ctx, "user_id = $1", userID)
+}
 
-	if avatar.Valid {
-		user.AvatarPath = avatar.String
-	} else {
-		user.AvatarPath = ""
-	}
+func (r *PostgresUserRepo) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	return r.getUser(ctx, "LOWER(login) = LOWER($1)", username)
+}
 
-	return user, nil
+func (r *PostgresUserRepo) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	return r.getUser(ctx, "LOWER(email) = LOWER($1)", email)
 }
 
 func (r *PostgresUserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
-	query := `SELECT COUNT(*) FROM users WHERE email = $1`
+	query := `SELECT COUNT(*) FROM users WHERE LOWER(email) = LOWER($1)`
 
 	var count int
 	err := r.db.QueryRowContext(ctx, query, email).Scan(&count)
@@ -95,7 +125,7 @@ func (r *PostgresUserRepo) ExistsByEmail(ctx context.Context, email string) (boo
 }
 
 func (r *PostgresUserRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
-	query := `SELECT COUNT(*) FROM users WHERE login = $1`
+	query := `SELECT COUNT(*) FROM users WHERE LOWER(login) = LOWER($1)`
 
 	var count int
 	err := r.db.QueryRowContext(ctx, query, username).Scan(&count)
@@ -139,11 +169,91 @@ func (s *PostgresUserRepo) UpdateUserProfile(ctx context.Context, userData domai
 
 	finalQuery := fmt.Sprintf(baseQuery, avatarQuery)
 
-	_, err := s.db.Exec(finalQuery, args...)
+	res, err := s.db.ExecContext(ctx, finalQuery, args...)
 	if err != nil {
 		slog.Debug("Помилка при оновленні профіля користувача", "err", err.Error())
+		return userWriteError(err)
+	}
+
+	return userAffected(res)
+}
+
+// UserIdentifiers — логін і email облікового запису в тому вигляді, як їх збережено.
+type UserIdentifiers struct {
+	UserID int
+	Login  string
+	Email  string
+}
+
+// ListIdentifiers повертає логіни й email усіх користувачів для перенормалізації з CLI.
+func (r *PostgresUserRepo) ListIdentifiers(ctx context.Context) ([]UserIdentifiers, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, login, email FROM users ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []UserIdentifiers
+	for rows.Next() {
+		var u UserIdentifiers
+		if err := rows.Scan(&u.UserID, &u.Login, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// UpdateIdentifiers замінює логін і email; зайняте значення повертається як ErrEmailTaken чи ErrLoginTaken.
+func (r *PostgresUserRepo) UpdateIdentifiers(ctx context.Context, userID int, login, email string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET login = $2, email = $3 WHERE user_id = $1`, userID, login, email)
+	if err != nil {
+		return userWriteError(err)
+	}
+	return userAffected(res)
+}
+
+func (r *PostgresUserRepo) UpdateUserStatus(ctx context.Context, userID int, status domain.UserStatus, reason string, until *time.Time) error {
+	query := `UPDATE users SET status = $2, status_reason = NULLIF($3, ''), status_until = $4 WHERE user_id = $1`
+
+	res, err := r.db.ExecContext(ctx, query, userID, status, reason, until)
+	if err != nil {
+		slog.Debug("Помилка при оновленні статусу користувача", "err", err.Error())
 		return err
 	}
 
-	return nil
+	return userAffected(res)
+}
+
+func (r *PostgresUserRepo) SetPasswordResetRequired(ctx context.Context, userID int, required bool) error {
+	query := `UPDATE users SET password_reset_required = $2 WHERE user_id = $1`
+
+	res, err := r.db.ExecContext(ctx, query, userID, required)
+	if err != nil {
+		slog.Debug("Помилка при оновленні вимоги скидання пароля", "err", err.Error())
+		return err
+	}
+	return userAffected(res)
+}
+
+func (r *PostgresUserRepo) UpdatePassword(ctx context.Context, userID int, hashPassword string) error {
+	query := `UPDATE users SET hash_password = $2, password_reset_required = FALSE WHERE user_id = $1`
+
+	res, err := r.db.ExecContext(ctx, query, userID, hashPassword)
+	if err != nil {
+		slog.Debug("Помилка при оновленні пароля", "err", err.Error())
+		return err
+	}
+	return userAffected(res)
+}
+
+func (r *PostgresUserRepo) SetOrganization(ctx context.Context, userID, orgID int) error {
+	query := `UPDATE users SET org_id = $2 WHERE user_id = $1`
+
+	res, err := r.db.ExecContext(ctx, query, userID, orgID)
+	if err != nil {
+		slog.Debug("Помилка при оновленні організації користувача", "err", err.Error())
+		return err
+	}
+	return userAffected(res)
 }
@@ -4,29 +4,111 @@ import (
 	"log/slog"
 	"net/http"
 	"sso-service/internal/delivery/http_handlers"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/clientip"
+	"sso-service/internal/lib/i18n"
+	"sso-service/internal/lib/responseHTTP"
 	"sso-service/pkg/auth"
 
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(usersHandler *http_handlers.UsersHandler) http.Handler {
+// Handlers збирає обробники всіх груп маршрутів.
+type Handlers struct {
+	Users        *http_handlers.UsersHandler
+	Audit        *http_handlers.AuditHandler
+	Sessions     *http_handlers.SessionsHandler
+	Security     *http_handlers.SecurityHandler
+	OAuth        *http_handlers.OAuthHandler
+	OAuthServer  *http_handlers.OAuthServerHandler
+	AccessTokens *http_handlers.AccessTokensHandler
+	Consent      *http_handlers.ConsentHandler
+	Keys         *http_handlers.KeysHandler
+	ForwardAuth  *http_handlers.ForwardAuthHandler
+}
+
+// sensitive захищає маршрут токеном і забороняє його під час імперсонації.
+func sensitive(fn func(w http.ResponseWriter, r *http.Request)) http.Handler {
+	return auth.AuthMiddlewareHandler(auth.DenyImpersonation(http.HandlerFunc(fn)))
+}
+
+// scoped відкриває маршрут персональним токенам і токенам застосунків з указаними дозволами.
+// Решта маршрутів через AuthMiddleware приймає лише токени самого користувача.
+func scoped(fn func(w http.ResponseWriter, r *http.Request), scopes ...string) http.Handler {
+	return auth.RequireScope(scopes...)(http.HandlerFunc(fn))
+}
+
+// NewRouter збирає маршрути; proxies визначає, яким проксі можна вірити щодо адреси клієнта.
+func NewRouter(h Handlers, stepUp auth.StepUpPolicy, proxies *clientip.Resolver) http.Handler {
 	router := mux.NewRouter()
+	router.Use(http_handlers.RouteTemplate)
+
+	// recent, на відміну від sensitive, ще й вимагає свіжої автентифікації.
+	recent := func(fn func(w http.ResponseWriter, r *http.Request)) http.Handler {
+		return auth.AuthMiddlewareHandler(auth.DenyImpersonation(auth.RequireStepUp(stepUp)(http.HandlerFunc(fn))))
+	}
+
+	router.HandleFunc("/api/sso/register", h.Users.RegisterHandler).Methods("POST")
+	router.HandleFunc("/api/sso/login", h.Users.LoginHandler).Methods("POST")
+	router.HandleFunc("/api/sso/reactivate", h.Users.ReactivateHandler).Methods("POST")
+	router.Handle("/api/sso/refresh", sensitive(h.Users.RefreshHandler)).Methods("POST")
+	router.HandleFunc("/api/sso/session/refresh", h.Users.SessionRefreshHandler).Methods("POST")
+	router.Handle("/api/sso/session/logout", auth.AuthMiddleware(h.Users.LogoutHandler)).Methods("POST")
+	router.Handle("/api/sso/reauthenticate", sensitive(h.Users.ReauthenticateHandler)).Methods("POST")
+	router.HandleFunc("/api/sso/password_reset/request", h.Security.RequestPasswordResetHandler).Methods("POST")
+	router.HandleFunc("/api/sso/password_reset", h.Security.ResetPasswordHandler).Methods("POST")
+	router.HandleFunc("/api/sso/security/not_me", h.Security.NotMePageHandler).Methods("GET")
+	router.HandleFunc("/api/sso/security/not_me", h.Security.NotMeHandler).Methods("POST")
+
+	router.HandleFunc("/api/sso/oauth/{provider}/login", h.OAuth.LoginRedirectHandler).Methods("GET")
+	router.HandleFunc("/api/sso/oauth/{provider}/callback", h.OAuth.CallbackHandler).Methods("GET", "POST")
+	router.HandleFunc("/api/sso/saml/{partner}/metadata", h.OAuth.SAMLMetadataHandler).Methods("GET")
+	router.HandleFunc("/api/sso/saml/{partner}/login", h.OAuth.SAMLLoginHandler).Methods("GET")
+	router.HandleFunc("/api/sso/saml/{partner}/acs", h.OAuth.SAMLACSHandler).Methods("POST")
+
+	router.HandleFunc("/api/sso/device/code", h.OAuthServer.DeviceCodeHandler).Methods("POST")
+	router.Handle("/api/sso/device/verify", auth.AuthMiddleware(h.OAuthServer.DeviceVerificationHandler)).Methods("GET")
+	router.Handle("/api/sso/device/verify", sensitive(h.OAuthServer.DeviceApproveHandler)).Methods("POST")
+	router.HandleFunc("/api/sso/token", h.OAuthServer.TokenHandler).Methods("POST")
+	router.HandleFunc("/api/sso/auth/verify", h.ForwardAuth.VerifyHandler).Methods("GET")
+	router.HandleFunc("/api/sso/introspect", h.OAuthServer.IntrospectHandler).Methods("POST")
+	router.HandleFunc("/api/sso/.well-known/jwks.json", h.Keys.JWKSHandler).Methods("GET")
+	router.Handle("/api/sso/userinfo", scoped(h.OAuthServer.UserInfoHandler)).Methods("GET")
+	router.Handle("/api/sso/consent", auth.AuthMiddleware(h.Consent.ConsentPromptHandler)).Methods("GET")
+	router.Handle("/api/sso/consent", sensitive(h.Consent.ConsentDecisionHandler)).Methods("POST")
+
+	router.Handle("/api/sso/user_profile", scoped(h.Users.UserProfileHandler, domain.ScopeProfile)).Methods("GET")
+	router.Handle("/api/sso/update_user_profile", auth.AuthMiddleware(h.Users.UpdateUserProfileHandler)).Methods("PUT")
+	router.Handle("/api/sso/user_profile/activity", auth.AuthMiddleware(h.Audit.UserActivityHandler)).Methods("GET")
+	router.Handle("/api/sso/user_profile/deactivate", sensitive(h.Users.DeactivateHandler)).Methods("POST")
+	router.Handle("/api/sso/user_profile/identities", auth.AuthMiddleware(h.OAuth.ListIdentitiesHandler)).Methods("GET")
+	router.Handle("/api/sso/user_profile/identities/{provider}", recent(h.OAuth.LinkIdentityHandler)).Methods("POST")
+	router.Handle("/api/sso/user_profile/identities/{provider}", recent(h.OAuth.UnlinkIdentityHandler)).Methods("DELETE")
+
+	router.Handle("/api/sso/user_profile/consents", auth.AuthMiddleware(h.Consent.ListConsentsHandler)).Methods("GET")
+	router.Handle("/api/sso/user_profile/consents/{client_id}", auth.AuthMiddleware(h.Consent.RevokeConsentHandler)).Methods("DELETE")
+
+	router.Handle("/api/sso/sessions", auth.AuthMiddleware(h.Sessions.ListSessionsHandler)).Methods("GET")
+	router.Handle("/api/sso/sessions/{id}", auth.AuthMiddleware(h.Sessions.RevokeSessionHandler)).Methods("DELETE")
 
-	router.HandleFunc("/api/sso/register", usersHandler.RegisterHandler).Methods("POST")
-	router.HandleFunc("/api/sso/login", usersHandler.LoginHandler).Methods("POST")
+	router.Handle("/api/sso/tokens", recent(h.AccessTokens.CreateTokenHandler)).Methods("POST")
+	router.Handle("/api/sso/tokens", auth.AuthMiddleware(h.AccessTokens.ListTokensHandler)).Methods("GET")
+	router.Handle("/api/sso/tokens/{id:[0-9]+}", auth.AuthMiddleware(h.AccessTokens.RevokeTokenHandler)).Methods("DELETE")
 
-	router.Handle("/api/sso/user_profile", auth.AuthMiddleware(usersHandler.UserProfileHandler)).Methods("GET")
-	router.Handle("/api/sso/update_user_profile", auth.AuthMiddleware(usersHandler.UpdateUserProfileHandler)).Methods("PUT")
+	router.Handle("/api/sso/admin/users/{user_id:[0-9]+}/suspend", auth.AuthMiddleware(h.Users.RequireAdmin(h.Users.SuspendUserHandler))).Methods("POST")
+	router.Handle("/api/sso/admin/users/{user_id:[0-9]+}/unsuspend", auth.AuthMiddleware(h.Users.RequireAdmin(h.Users.UnsuspendUserHandler))).Methods("POST")
+	router.Handle("/api/sso/admin/users/{user_id:[0-9]+}/impersonate", recent(h.Users.RequireAdmin(h.Users.ImpersonateHandler))).Methods("POST")
+	router.Handle("/api/sso/admin/audit", auth.AuthMiddleware(h.Users.RequireAdmin(h.Audit.AdminAuditHandler))).Methods("GET")
 
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		slog.Debug("Маршрут не знайдено", "method", r.Method, "path", r.URL.Path)
-		http.Error(w, "Маршрут не знайдено", http.StatusNotFound)
+		slog.DebugContext(r.Context(), "Маршрут не знайдено", "method", r.Method, "path", r.URL.Path)
+		responseHTTP.JSONError(w, r, http.StatusNotFound, i18n.RouteNotFound)
 	})
 
 	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		slog.Debug("Заборонений метод", "method", r.Method, "path", r.URL.Path)
-		http.Error(w, "Заборонений метод", http.StatusMethodNotAllowed)
+		slog.DebugContext(r.Context(), "Заборонений метод", "method", r.Method, "path", r.URL.Path)
+		responseHTTP.JSONError(w, r, http.StatusMethodNotAllowed, i18n.MethodNotAllowed)
 	})
 
-	return router
+	return http_handlers.RequestID(http_handlers.ClientAddress(proxies)(http_handlers.AccessLog(router)))
 }
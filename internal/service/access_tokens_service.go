@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+	"strings"
+	"time"
+)
+
+const maxTokenNameLength = 100
+
+type AccessTokensService struct {
+	repo  domain.PersonalAccessTokenRepository
+	users domain.UserRepository
+}
+
+func NewAccessTokensService(repo domain.PersonalAccessTokenRepository, users domain.UserRepository) *AccessTokensService {
+	return &AccessTokensService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create випускає токен і повертає його відкрите значення разом з метаданими.
+func (s *AccessTokensService) Create(ctx context.Context, userID int, req domain.CreateAccessTokenRequest) (string, domain.PersonalAccessToken, error) {
+	name := strings.TrimSpace(req.Name)
+	invalid := (&domain.ValidationError{}).
+		Check("name", name, true, domain.Length(1, maxTokenNameLength), domain.Chars(domain.TextChars))
+	if len(req.Scopes) == 0 {
+		invalid.Add("scopes", domain.RuleRequired, nil)
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(domain.PersonalTokenScopes, scope) {
+			invalid.Add("scopes", domain.RuleUnknownValue, domain.Params{"value": scope})
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		invalid.Add("expires_at", domain.RuleNotInFuture, nil)
+	}
+	if err := invalid.OrNil(); err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+
+	if req.OrgID != nil {
+		user, err := s.users.GetByID(ctx, userID)
+		if err != nil {
+			return "", domain.PersonalAccessToken{}, err
+		}
+		if user.OrgID == nil || *user.OrgID != *req.OrgID {
+			return "", domain.PersonalAccessToken{}, domain.NewValidationError("org_id", domain.RuleNotMember, domain.Params{"org_id": *req.OrgID})
+		}
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+	plain := domain.PersonalTokenPrefix + secret
+
+	token := domain.PersonalAccessToken{
+		UserID:    userID,
+		OrgID:     req.OrgID,
+		Name:      name,
+		Prefix:    plain[:len(domain.PersonalTokenPrefix)+6],
+		TokenHash: hashAccessToken(plain),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	token.ID, err = s.repo.CreateToken(ctx, token)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+
+	return plain, token, nil
+}
+
+func (s *AccessTokensService) List(ctx context.Context, userID int) ([]domain.PersonalAccessToken, error) {
+	return s.repo.ListTokens(ctx, userID)
+}
+
+func (s *AccessTokensService) Revoke(ctx context.Context, userID, tokenID int) error {
+	return s.repo.RevokeToken(ctx, userID, tokenID)
+}
+
+// Resolve — OpaqueTokenResolver для pkg/auth: перевіряє персональний токен і повертає claims власника.
+func (s *AccessTokensService) Resolve(ctx context.Context, plain string) (*auth.JWTToken, error) {
+	token, err := s.repo.GetTokenByHash(ctx, hashAccessToken(plain))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		return nil, domain.NewError(domain.ErrUnauthorized, "token revoked")
+	}
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, domain.NewError(domain.ErrUnauthorized, "token expired")
+	}
+
+	user, err := s.users.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > touchInterval {
+		if err := s.repo.TouchToken(ctx, token.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ролей власника токен не несе: його права обмежені дозволами, а перевірка ролі,
+	// що не дивиться на Delegated, інакше прийняла б токен адміністратора за адміністратора.
+	claims := &auth.JWTToken{
+		Username:   user.Login,
+		UserID:     user.UserID,
+		Scope:      strings.Join(token.Scopes, " "),
+		AuthMethod: "pat",
+	}
+	claims.Id = fmt.Sprintf("pat:%d", token.ID)
+	if token.OrgID != nil {
+		claims.OrgID = *token.OrgID
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sso-service/internal/domain"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type AuditService struct {
+	repo domain.AuditRepository
+}
+
+func NewAuditService(repo domain.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record зберігає подію аудиту. Помилка запису лише логується,
+// щоб збій журналу не ламав сам запит користувача.
+func (s *AuditService) Record(ctx context.Context, event domain.AuditEvent) {
+	if err := s.repo.Append(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "Не вдалося записати подію аудиту", "type", event.Type, "err", err.Error())
+	}
+}
+
+func (s *AuditService) UserActivity(ctx context.Context, userID, limit, offset int) ([]domain.AuditEvent, error) {
+	return s.Query(ctx, domain.AuditFilter{UserID: &userID, Limit: limit, Offset: offset})
+}
+
+func (s *AuditService) Query(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.List(ctx, filter)
+}
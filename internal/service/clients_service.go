@@ -0,0 +1,56 @@
+package service
+
+import (
+	"crypto/subtle"
+	"sso-service/internal/domain"
+)
+
+// ClientsService — реєстр OAuth-клієнтів з конфігурації.
+type ClientsService struct {
+	clients map[string]domain.OAuthClient
+}
+
+func NewClientsService(clients []domain.OAuthClient) *ClientsService {
+	registry := make(map[string]domain.OAuthClient, len(clients))
+	for _, client := range clients {
+		registry[client.ClientID] = client
+	}
+
+	return &ClientsService{clients: registry}
+}
+
+func (s *ClientsService) Get(clientID string) (domain.OAuthClient, bool) {
+	client, ok := s.clients[clientID]
+	return client, ok
+}
+
+// Authenticate перевіряє клієнта. Публічні клієнти (пристрої, мобільні застосунки) секрету не мають.
+func (s *ClientsService) Authenticate(clientID, secret string) (domain.OAuthClient, error) {
+	client, ok := s.clients[clientID]
+	if !ok {
+		return client, domain.ErrInvalidClient
+	}
+
+	if client.Public {
+		return client, nil
+	}
+
+	if client.Secret == "" || subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1 {
+		return client, domain.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// AuthenticateConfidential перевіряє клієнта із секретом. Публічні клієнти тут не проходять:
+// їхній client_id вшитий у застосунок і нічого не доводить.
+func (s *ClientsService) AuthenticateConfidential(clientID, secret string) (domain.OAuthClient, error) {
+	client, err := s.Authenticate(clientID, secret)
+	if err != nil {
+		return client, err
+	}
+	if client.Public {
+		return client, domain.ErrInvalidClient
+	}
+	return client, nil
+}
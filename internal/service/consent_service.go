@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+)
+
+// ConsentService зберігає згоди користувачів на доступ сторонніх застосунків до їхніх даних.
+type ConsentService struct {
+	repo    domain.ConsentRepository
+	clients *ClientsService
+}
+
+func NewConsentService(repo domain.ConsentRepository, clients *ClientsService) *ConsentService {
+	return &ConsentService{
+		repo:    repo,
+		clients: clients,
+	}
+}
+
+// ValidateScopes перевіряє, що дозволи відомі й дозволені клієнту.
+// Сторонній клієнт мусить запитати хоча б один дозвіл: токен без scope означає повний доступ.
+func (s *ConsentService) ValidateScopes(client domain.OAuthClient, scopes []string) error {
+	if len(scopes) == 0 && !client.Trusted {
+		return domain.ErrInvalidScope
+	}
+
+	for _, scope := range scopes {
+		if _, ok := domain.LookupScope(scope); !ok {
+			return domain.ErrInvalidScope
+		}
+		if len(client.Scopes) > 0 && !slices.Contains(client.Scopes, scope) {
+			return domain.ErrInvalidScope
+		}
+	}
+
+	return nil
+}
+
+func (s *ConsentService) granted(ctx context.Context, userID int, clientID string) ([]string, error) {
+	grant, err := s.repo.GetConsent(ctx, userID, clientID)
+	if errors.Is(err, domain.ErrConsentNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return grant.Scopes, nil
+}
+
+// Prompt повертає дані для екрана згоди.
+func (s *ConsentService) Prompt(ctx context.Context, userID int, clientID string, scopes []string) (domain.ConsentPromptResponse, error) {
+	client, ok := s.clients.Get(clientID)
+	if !ok {
+		return domain.ConsentPromptResponse{}, domain.ErrInvalidClient
+	}
+
+	if err := s.ValidateScopes(client, scopes); err != nil {
+		return domain.ConsentPromptResponse{}, err
+	}
+
+	granted, err := s.granted(ctx, userID, clientID)
+	if err != nil {
+		return domain.ConsentPromptResponse{}, err
+	}
+
+	resp := domain.ConsentPromptResponse{
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Requested:  []domain.ScopeInfo{},
+		Granted:    granted,
+	}
+	for _, scope := range scopes {
+		info, _ := domain.LookupScope(scope)
+		resp.Requested = append(resp.Requested, info)
+		if !slices.Contains(granted, scope) {
+			resp.Required = !client.Trusted
+		}
+	}
+
+	return resp, nil
+}
+
+// Grant додає дозволи до вже наданих клієнту.
+func (s *ConsentService) Grant(ctx context.Context, userID int, clientID string, scopes []string) error {
+	client, ok := s.clients.Get(clientID)
+	if !ok {
+		return domain.ErrInvalidClient
+	}
+
+	if err := s.ValidateScopes(client, scopes); err != nil {
+		return err
+	}
+
+	granted, err := s.granted(ctx, userID, clientID)
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	return s.repo.SaveConsent(ctx, domain.ConsentGrant{UserID: userID, ClientID: clientID, Scopes: granted})
+}
+
+// Effective обмежує запитані дозволи тими, на які користувач погодився.
+// Довіреним клієнтам дозволи не обмежуються.
+func (s *ConsentService) Effective(ctx context.Context, userID int, client domain.OAuthClient, requested []string) ([]string, error) {
+	if client.Trusted {
+		return requested, nil
+	}
+
+	granted, err := s.granted(ctx, userID, client.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := []string{}
+	for _, scope := range requested {
+		if slices.Contains(granted, scope) {
+			effective = append(effective, scope)
+		}
+	}
+	return effective, nil
+}
+
+func (s *ConsentService) List(ctx context.Context, userID int) ([]domain.ConsentGrant, error) {
+	grants, err := s.repo.ListConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range grants {
+		if client, ok := s.clients.Get(grants[i].ClientID); ok {
+			grants[i].ClientName = client.Name
+		}
+	}
+	return grants, nil
+}
+
+func (s *ConsentService) Revoke(ctx context.Context, userID int, clientID string) error {
+	return s.repo.RevokeConsent(ctx, userID, clientID)
+}
+
+// CheckToken відхиляє токени стороннього клієнта, якщо користувач відкликав згоду
+// або звузив її так, що токен має більше дозволів, ніж надано.
+func (s *ConsentService) CheckToken(ctx context.Context, token *auth.JWTToken) error {
+	if token.ClientID == "" {
+		return nil
+	}
+
+	client, ok := s.clients.Get(token.ClientID)
+	if !ok {
+		return domain.ErrInvalidClient
+	}
+	if client.Trusted {
+		return nil
+	}
+
+	if token.Scope == "" {
+		return domain.ErrInvalidScope
+	}
+
+	granted, err := s.granted(ctx, token.UserID, client.ClientID)
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range domain.ParseScope(token.Scope) {
+		if !slices.Contains(granted, scope) {
+			return domain.ErrConsentNotFound
+		}
+	}
+	return nil
+}
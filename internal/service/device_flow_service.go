@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sso-service/internal/domain"
+	"strings"
+	"time"
+)
+
+// userCodeAlphabet без голосних і схожих символів, щоб код було легко ввести і він не складав слів.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+const slowDownStep = 5 * time.Second
+
+type DeviceFlowService struct {
+	repo            domain.DeviceAuthorizationRepository
+	verificationURL string
+	codeTTL         time.Duration
+	interval        time.Duration
+}
+
+func NewDeviceFlowService(repo domain.DeviceAuthorizationRepository, verificationURL string, codeTTL, interval time.Duration) *DeviceFlowService {
+	return &DeviceFlowService{
+		repo:            repo,
+		verificationURL: verificationURL,
+		codeTTL:         codeTTL,
+		interval:        interval,
+	}
+}
+
+func hashDeviceCode(deviceCode string) string {
+	sum := sha256.Sum256([]byte(deviceCode))
+	return hex.EncodeToString(sum[:])
+}
+
+// newUserCode бере лише байти з діапазону, кратного довжині алфавіту: остача від решти
+// давала б першим літерам алфавіту більшу ймовірність.
+func newUserCode() (string, error) {
+	limit := 256 - 256%len(userCodeAlphabet)
+
+	code := make([]byte, 0, 9)
+	b := make([]byte, 16)
+	for len(code) < 9 {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for _, v := range b {
+			if int(v) >= limit || len(code) == 9 {
+				continue
+			}
+			if len(code) == 4 {
+				code = append(code, '-')
+			}
+			code = append(code, userCodeAlphabet[int(v)%len(userCodeAlphabet)])
+		}
+	}
+
+	return string(code), nil
+}
+
+// NormalizeUserCode приводить введений користувачем код до формату XXXX-XXXX.
+func NormalizeUserCode(input string) string {
+	code := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(input)))
+
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+func (s *DeviceFlowService) Start(ctx context.Context, client domain.OAuthClient, scope string) (domain.DeviceCodeResponse, error) {
+	deviceCode, err := randomHex(32)
+	if err != nil {
+		return domain.DeviceCodeResponse{}, err
+	}
+
+	userCode, err := newUserCode()
+	if err != nil {
+		return domain.DeviceCodeResponse{}, err
+	}
+
+	auth := domain.DeviceAuthorization{
+		DeviceCodeHash: hashDeviceCode(deviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ClientID,
+		Scope:          scope,
+		Status:         domain.DeviceAuthPending,
+		Interval:       s.interval,
+		ExpiresAt:      time.Now().Add(s.codeTTL),
+	}
+
+	if err := s.repo.CreateDeviceAuthorization(ctx, auth); err != nil {
+		return domain.DeviceCodeResponse{}, err
+	}
+
+	return domain.DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         s.verificationURL,
+		VerificationURIComplete: s.verificationURL + "?user_code=" + url.QueryEscape(userCode),
+		ExpiresIn:               int(s.codeTTL.Seconds()),
+		Interval:                int(s.interval.Seconds()),
+	}, nil
+}
+
+// Pending повертає запит, що очікує рішення користувача, для сторінки підтвердження.
+func (s *DeviceFlowService) Pending(ctx context.Context, userCode string) (domain.DeviceAuthorization, error) {
+	auth, err := s.repo.GetByUserCode(ctx, NormalizeUserCode(userCode))
+	if err != nil {
+		return auth, err
+	}
+
+	if auth.Status != domain.DeviceAuthPending || time.Now().After(auth.ExpiresAt) {
+		return auth, fmt.Errorf("device authorization is no longer pending")
+	}
+
+	return auth, nil
+}
+
+func (s *DeviceFlowService) Decide(ctx context.Context, userCode string, userID int, approve bool) error {
+	status := domain.DeviceAuthDenied
+	if approve {
+		status = domain.DeviceAuthApproved
+	}
+
+	return s.repo.DecideDeviceAuthorization(ctx, NormalizeUserCode(userCode), status, userID)
+}
+
+// Poll обробляє опитування /token пристроєм. Повертає схвалений запит рівно один раз.
+func (s *DeviceFlowService) Poll(ctx context.Context, clientID, deviceCode string) (domain.DeviceAuthorization, error) {
+	hash := hashDeviceCode(deviceCode)
+
+	auth, err := s.repo.GetByDeviceCode(ctx, hash)
+	if err != nil || auth.ClientID != clientID {
+		return auth, domain.ErrInvalidGrant
+	}
+
+	now := time.Now()
+	if now.After(auth.ExpiresAt) {
+		return auth, domain.ErrExpiredToken
+	}
+
+	interval := auth.Interval
+	tooFast := auth.LastPolledAt != nil && now.Sub(*auth.LastPolledAt) < auth.Interval
+	if tooFast {
+		interval += slowDownStep
+	}
+	if err := s.repo.RecordDevicePoll(ctx, hash, now, interval); err != nil {
+		return auth, err
+	}
+	if tooFast {
+		return auth, domain.ErrSlowDown
+	}
+
+	switch auth.Status {
+	case domain.DeviceAuthPending:
+		return auth, domain.ErrAuthorizationPending
+	case domain.DeviceAuthDenied:
+		return auth, domain.ErrAccessDenied
+	case domain.DeviceAuthApproved:
+		consumed, err := s.repo.ConsumeDeviceAuthorization(ctx, hash)
+		if err != nil {
+			return auth, err
+		}
+		if !consumed {
+			return auth, domain.ErrInvalidGrant
+		}
+		return auth, nil
+	default:
+		return auth, domain.ErrInvalidGrant
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+	"strings"
+	"time"
+)
+
+const reportLinkTTL = 7 * 24 * time.Hour
+
+type DevicesService struct {
+	repo          domain.DeviceRepository
+	usedTokens    domain.UsedTokenRepository
+	notifications *NotificationsService
+}
+
+func NewDevicesService(repo domain.DeviceRepository, usedTokens domain.UsedTokenRepository, notifications *NotificationsService) *DevicesService {
+	return &DevicesService{
+		repo:          repo,
+		usedTokens:    usedTokens,
+		notifications: notifications,
+	}
+}
+
+// ipPrefix відкидає хостову частину адреси (/24 для IPv4, /48 для IPv6),
+// щоб зміна адреси в межах мережі провайдера не вважалася новим пристроєм.
+func ipPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+func deviceFingerprint(userAgent, prefix string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(userAgent)) + "|" + prefix))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckLogin запам'ятовує пристрій і, якщо вхід виконано з нового пристрою або країни,
+// надсилає користувачу лист із посиланням "це був не я". Повертає true, якщо вхід вважається новим.
+// Перший вхід користувача не сповіщається — порівнювати ще нема з чим.
+func (s *DevicesService) CheckLogin(ctx context.Context, user domain.User, sessionID, ip, userAgent, country string) (bool, error) {
+	prefix := ipPrefix(ip)
+	device := domain.KnownDevice{
+		UserID:      user.UserID,
+		Fingerprint: deviceFingerprint(userAgent, prefix),
+		UserAgent:   userAgent,
+		IPPrefix:    prefix,
+		Country:     strings.ToUpper(country),
+		LastSeenAt:  time.Now(),
+	}
+
+	count, err := s.repo.CountDevices(ctx, user.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	knownDevice, err := s.repo.IsKnownDevice(ctx, user.UserID, device.Fingerprint)
+	if err != nil {
+		return false, err
+	}
+
+	knownCountry := true
+	if device.Country != "" {
+		knownCountry, err = s.repo.IsKnownCountry(ctx, user.UserID, device.Country)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := s.repo.RememberDevice(ctx, device); err != nil {
+		return false, err
+	}
+
+	if count == 0 || (knownDevice && knownCountry) {
+		return false, nil
+	}
+
+	reportToken, err := auth.IssueActionToken(auth.ActionToken{
+		Purpose:   auth.PurposeReportLogin,
+		UserID:    user.UserID,
+		SessionID: sessionID,
+	}, reportLinkTTL)
+	if err != nil {
+		return true, err
+	}
+
+	go func() {
+		if err := s.notifications.NewDeviceLogin(context.WithoutCancel(ctx), user, device, ip, reportToken); err != nil {
+			slog.ErrorContext(ctx, "Не вдалося надіслати лист про новий вхід", "user_id", user.UserID, "err", err.Error())
+		}
+	}()
+
+	return true, nil
+}
+
+// ParseReport перевіряє токен посилання "це був не я", не використовуючи його.
+func (s *DevicesService) ParseReport(token string) (*auth.ActionToken, error) {
+	claims, err := auth.ParseActionToken(token, auth.PurposeReportLogin)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrUnauthorized, "invalid report token: %v", err)
+	}
+	return claims, nil
+}
+
+// ConsumeReport перевіряє токен посилання "це був не я" і позначає його використаним:
+// повторний перехід за тим самим посиланням уже нічого не робить.
+func (s *DevicesService) ConsumeReport(ctx context.Context, token string) (*auth.ActionToken, error) {
+	claims, err := s.ParseReport(token)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	first, err := s.usedTokens.MarkUsed(ctx, auth.PurposeReportLogin+":"+hex.EncodeToString(sum[:]), time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, domain.NewError(domain.ErrUnauthorized, "report token already used")
+	}
+
+	return claims, nil
+}
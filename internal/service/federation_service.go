@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sso-service/internal/domain"
+	"sso-service/internal/federation"
+	"sso-service/pkg/auth"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+// FederationResult описує результат входу через зовнішнього провайдера.
+type FederationResult struct {
+	User domain.User
+	// Linked — ідентичність щойно прив'язано до користувача.
+	Linked bool
+	// Created — користувача створено під час цього входу.
+	Created bool
+	// LinkOnly — потік ініційовано з профілю для прив'язки, токен видавати не треба.
+	LinkOnly bool
+}
+
+type FederationService struct {
+	providers    map[string]federation.Provider
+	samlPartners map[string]*federation.SAMLPartner
+	identities   domain.IdentityRepository
+	users        domain.UserRepository
+	orgs         domain.OrganizationRepository
+	usedTokens   domain.UsedTokenRepository
+}
+
+func NewFederationService(providers map[string]federation.Provider, samlPartners map[string]*federation.SAMLPartner,
+	identities domain.IdentityRepository, users domain.UserRepository, orgs domain.OrganizationRepository,
+	usedTokens domain.UsedTokenRepository) *FederationService {
+	return &FederationService{
+		providers:    providers,
+		samlPartners: samlPartners,
+		identities:   identities,
+		users:        users,
+		orgs:         orgs,
+		usedTokens:   usedTokens,
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AuthURL повертає адресу провайдера для входу або, якщо linkUserID не нульовий, для прив'язки до профілю.
+// State підписаний і містить nonce, тому сервер не зберігає стан між редиректами. Той самий nonce
+// повертається окремо: його треба покласти в cookie браузера, щоб Complete прийняв state лише від того
+// браузера, який почав вхід, а не від жертви, якій підсунули чуже посилання.
+func (s *FederationService) AuthURL(providerName string, linkUserID int) (authURL, nonce string, err error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", fmt.Errorf("unknown provider %q", providerName)
+	}
+
+	nonce, err = randomHex(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := auth.IssueActionToken(auth.ActionToken{
+		Purpose:  auth.PurposeOAuthState,
+		UserID:   linkUserID,
+		Provider: providerName,
+		Nonce:    nonce,
+	}, oauthStateTTL)
+	if err != nil {
+		return "", "", err
+	}
+
+	return provider.AuthCodeURL(state, nonce), nonce, nil
+}
+
+// Complete завершує вхід або прив'язку. browserNonce — значення cookie, покладеної під час AuthURL.
+func (s *FederationService) Complete(ctx context.Context, providerName, code, state, browserNonce string) (FederationResult, error) {
+	var result FederationResult
+
+	claims, err := auth.ParseActionToken(state, auth.PurposeOAuthState)
+	if err != nil {
+		return result, err
+	}
+	if claims.Provider != providerName {
+		return result, fmt.Errorf("state issued for another provider")
+	}
+	if browserNonce == "" || subtle.ConstantTimeCompare([]byte(browserNonce), []byte(claims.Nonce)) != 1 {
+		return result, fmt.Errorf("state was issued to another browser")
+	}
+
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return result, fmt.Errorf("unknown provider %q", providerName)
+	}
+
+	identity, err := provider.Exchange(ctx, code, claims.Nonce)
+	if err != nil {
+		return result, err
+	}
+
+	existing, err := s.identities.GetIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return result, err
+	}
+	if err == nil {
+		if claims.UserID != 0 && existing.UserID != claims.UserID {
+			return result, fmt.Errorf("identity is linked to another user")
+		}
+		result.User, err = s.users.GetByID(ctx, existing.UserID)
+		result.LinkOnly = claims.UserID != 0
+		return result, err
+	}
+
+	if claims.UserID != 0 {
+		result.User, err = s.users.GetByID(ctx, claims.UserID)
+		if err != nil {
+			return result, err
+		}
+		result.Linked, result.LinkOnly = true, true
+		return result, s.link(ctx, result.User.UserID, identity)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return result, fmt.Errorf("provider did not return a verified email")
+	}
+
+	result.User, err = s.users.GetByEmail(ctx, domain.NormalizeEmail(identity.Email))
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		result.User, err = s.createUser(ctx, identity, nil)
+		if err != nil {
+			return result, err
+		}
+		result.Created = true
+	case err != nil:
+		return result, err
+	}
+
+	result.Linked = true
+	return result, s.link(ctx, result.User.UserID, identity)
+}
+
+func (s *FederationService) link(ctx context.Context, userID int, identity federation.Identity) error {
+	return s.identities.LinkIdentity(ctx, domain.UserIdentity{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+}
+
+// createUser реєструє користувача за даними провайдера. Пароль випадковий —
+// за потреби користувач встановить власний через скидання пароля.
+func (s *FederationService) createUser(ctx context.Context, identity federation.Identity, orgID *int) (domain.User, error) {
+	login, err := s.uniqueLogin(ctx, identity.Login, identity.Email)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	password, err := randomHex(32)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	hashPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user := domain.User{
+		Login:        login,
+		Email:        domain.NormalizeEmail(identity.Email),
+		Role:         "user",
+		HashPassword: hashPassword,
+		FirstName:    identity.FirstName,
+		LastName:     identity.LastName,
+		Phonenumber:  identity.Phonenumber,
+		Address:      identity.Address,
+		Status:       domain.UserStatusActive,
+		OrgID:        orgID,
+	}
+
+	user.UserID, err = s.users.CreateUser(ctx, user)
+	return user, err
+}
+
+// uniqueLogin бере бажаний логін або локальну частину email і за потреби додає випадковий суфікс.
+func (s *FederationService) uniqueLogin(ctx context.Context, preferred, email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	if preferred != "" {
+		local = preferred
+	}
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' {
+			return r
+		}
+		return -1
+	}, domain.NormalizeLogin(local))
+	if base == "" {
+		base = "user"
+	}
+
+	login := base
+	for range 5 {
+		exists, err := s.users.ExistsByUsername(ctx, login)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return login, nil
+		}
+
+		suffix, err := randomHex(3)
+		if err != nil {
+			return "", err
+		}
+		login = base + "_" + suffix
+	}
+
+	return "", fmt.Errorf("could not generate unique login")
+}
+
+func (s *FederationService) Identities(ctx context.Context, userID int) ([]domain.UserIdentity, error) {
+	return s.identities.ListIdentities(ctx, userID)
+}
+
+// Unlink дозволений завжди: доступ без провайдера можна відновити через скидання пароля на email.
+func (s *FederationService) Unlink(ctx context.Context, userID int, provider string) error {
+	return s.identities.UnlinkIdentity(ctx, userID, provider)
+}
+
+func (s *FederationService) samlPartner(name string) (*federation.SAMLPartner, error) {
+	partner, ok := s.samlPartners[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown SAML partner %q", name)
+	}
+	return partner, nil
+}
+
+func (s *FederationService) SAMLMetadata(partnerName string) ([]byte, error) {
+	partner, err := s.samlPartner(partnerName)
+	if err != nil {
+		return nil, err
+	}
+	return partner.Metadata()
+}
+
+// SAMLAuthURL повертає адресу IdP партнера. RelayState підписаний і містить ID AuthnRequest.
+func (s *FederationService) SAMLAuthURL(partnerName string) (string, error) {
+	partner, err := s.samlPartner(partnerName)
+	if err != nil {
+		return "", err
+	}
+
+	return partner.AuthRedirectURL(func(requestID string) (string, error) {
+		return auth.IssueActionToken(auth.ActionToken{
+			Purpose:  auth.PurposeOAuthState,
+			Provider: "saml:" + partnerName,
+			Nonce:    requestID,
+		}, oauthStateTTL)
+	})
+}
+
+// CompleteSAML приймає відповідь IdP на ACS, знаходить або створює (just-in-time) користувача
+// і додає його до організації партнера.
+func (s *FederationService) CompleteSAML(ctx context.Context, partnerName string, r *http.Request) (FederationResult, error) {
+	var result FederationResult
+
+	partner, err := s.samlPartner(partnerName)
+	if err != nil {
+		return result, err
+	}
+
+	// Без RelayState відповідь вважається IdP-initiated; її прийме лише партнер з allow_idp_initiated.
+	var requestIDs []string
+	if relayState := r.FormValue("RelayState"); relayState != "" {
+		claims, err := auth.ParseActionToken(relayState, auth.PurposeOAuthState)
+		if err != nil {
+			return result, err
+		}
+		if claims.Provider != "saml:"+partnerName {
+			return result, fmt.Errorf("relay state issued for another partner")
+		}
+		requestIDs = []string{claims.Nonce}
+	}
+
+	assertion, err := partner.ParseResponse(r, requestIDs)
+	if err != nil {
+		return result, err
+	}
+	identity := assertion.Identity
+
+	// Підписане твердження чинне кілька хвилин; без цього його можна було б надіслати на ACS ще раз.
+	first, err := s.usedTokens.MarkUsed(ctx, "saml:"+partner.Name+":"+assertion.ID, assertion.NotOnOrAfter)
+	if err != nil {
+		return result, err
+	}
+	if !first {
+		return result, fmt.Errorf("assertion %s has already been used", assertion.ID)
+	}
+
+	org, err := s.orgs.EnsureOrganization(ctx, partner.Name, partner.Organization)
+	if err != nil {
+		return result, err
+	}
+
+	existing, err := s.identities.GetIdentity(ctx, identity.Provider, identity.Subject)
+	switch {
+	case err == nil:
+		result.User, err = s.users.GetByID(ctx, existing.UserID)
+		if err != nil {
+			return result, err
+		}
+	case !errors.Is(err, domain.ErrNotFound):
+		return result, err
+	case identity.Email == "":
+		return result, fmt.Errorf("assertion has no email")
+	case !partner.EmailAllowed(identity.Email):
+		return result, fmt.Errorf("email %s is outside partner domains", identity.Email)
+	default:
+		result.User, err = s.users.GetByEmail(ctx, domain.NormalizeEmail(identity.Email))
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			result.User, err = s.createUser(ctx, identity, &org.OrgID)
+			if err != nil {
+				return result, err
+			}
+			result.Created = true
+		case err != nil:
+			return result, err
+		}
+
+		result.Linked = true
+		if err := s.link(ctx, result.User.UserID, identity); err != nil {
+			return result, err
+		}
+	}
+
+	switch {
+	case result.User.OrgID == nil:
+		if err := s.users.SetOrganization(ctx, result.User.UserID, org.OrgID); err != nil {
+			return result, err
+		}
+		result.User.OrgID = &org.OrgID
+	case *result.User.OrgID != org.OrgID:
+		return result, fmt.Errorf("user belongs to another organization")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sso-service/pkg/auth"
+	"sync"
+	"time"
+)
+
+// forwardAuthCacheLimit — найбільше записів у кеші; понад нього витісняються найдавніше використані.
+const forwardAuthCacheLimit = 10000
+
+type forwardAuthEntry struct {
+	key       string
+	token     *auth.JWTToken
+	expiresAt time.Time
+}
+
+// ForwardAuthService перевіряє токени для API-шлюзу. Успішні перевірки кешуються на cacheTTL,
+// щоб гарячий шлях не ходив у БД; відкликання сесії чи блокування діє із затримкою до cacheTTL.
+// Кеш — LRU: потік нових токенів витісняє рідко використовувані, а не вимикає кешування.
+type ForwardAuthService struct {
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent — записи від щойно використаного до найдавнішого.
+	recent *list.List
+}
+
+func NewForwardAuthService(cacheTTL time.Duration) *ForwardAuthService {
+	return &ForwardAuthService{
+		cacheTTL: cacheTTL,
+		entries:  map[string]*list.Element{},
+		recent:   list.New(),
+	}
+}
+
+// Verify перевіряє токен для сервісу audience (порожній — маршрут без власного ідентифікатора):
+// токен, звужений обміном до іншого сервісу, не проходить.
+func (s *ForwardAuthService) Verify(ctx context.Context, tokenString, audience string) (*auth.JWTToken, error) {
+	sum := sha256.Sum256([]byte(audience + "\x00" + tokenString))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	if token, ok := s.cached(key, now); ok {
+		return token, nil
+	}
+
+	token, err := auth.ResolveTokenFor(ctx, tokenString, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	// Запис не переживає сам токен.
+	expiresAt := now.Add(s.cacheTTL)
+	if token.ExpiresAt != 0 && time.Unix(token.ExpiresAt, 0).Before(expiresAt) {
+		expiresAt = time.Unix(token.ExpiresAt, 0)
+	}
+
+	s.store(forwardAuthEntry{key: key, token: token, expiresAt: expiresAt})
+
+	return token, nil
+}
+
+func (s *ForwardAuthService) cached(key string, now time.Time) (*auth.JWTToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(forwardAuthEntry)
+	if !now.Before(entry.expiresAt) {
+		s.recent.Remove(elem)
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	s.recent.MoveToFront(elem)
+	return entry.token, true
+}
+
+func (s *ForwardAuthService) store(entry forwardAuthEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[entry.key]; ok {
+		elem.Value = entry
+		s.recent.MoveToFront(elem)
+		return
+	}
+
+	s.entries[entry.key] = s.recent.PushFront(entry)
+	for len(s.entries) > forwardAuthCacheLimit {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.entries, oldest.Value.(forwardAuthEntry).key)
+	}
+}
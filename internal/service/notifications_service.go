@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sso-service/internal/domain"
+	"sso-service/internal/lib/mailer"
+	"time"
+)
+
+type NotificationsService struct {
+	mailer    mailer.Mailer
+	publicURL string
+}
+
+func NewNotificationsService(mailer mailer.Mailer, publicURL string) *NotificationsService {
+	return &NotificationsService{
+		mailer:    mailer,
+		publicURL: publicURL,
+	}
+}
+
+func (s *NotificationsService) link(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", s.publicURL, path, url.QueryEscape(token))
+}
+
+func (s *NotificationsService) NewDeviceLogin(ctx context.Context, user domain.User, device domain.KnownDevice, ip, reportToken string) error {
+	country := device.Country
+	if country == "" {
+		country = "невідомо"
+	}
+
+	body := fmt.Sprintf(`Вітаємо, %s!
+
+Щойно виконано вхід у ваш обліковий запис CarVia з нового пристрою або країни.
+
+Час: %s
+Пристрій: %s
+IP-адреса: %s
+Країна: %s
+
+Якщо це були ви, нічого робити не потрібно.
+Якщо це були не ви, перейдіть за посиланням — сесію буде завершено, а пароль потрібно буде змінити:
+%s
+`, user.FirstName, device.LastSeenAt.Format(time.RFC1123), device.UserAgent, ip, country,
+		s.link("/api/sso/security/not_me", reportToken))
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Новий вхід в обліковий запис CarVia",
+		Body:    body,
+	})
+}
+
+func (s *NotificationsService) PasswordReset(ctx context.Context, user domain.User, resetToken string) error {
+	body := fmt.Sprintf(`Вітаємо, %s!
+
+Щоб встановити новий пароль для облікового запису CarVia, використайте цей токен у формі скидання пароля:
+%s
+
+Якщо ви не запитували скидання пароля, проігноруйте цей лист.
+`, user.FirstName, resetToken)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Скидання пароля CarVia",
+		Body:    body,
+	})
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// touchInterval обмежує частоту оновлення last_seen_at, щоб кожен запит не писав у БД.
+const touchInterval = time.Minute
+
+// SessionsService веде сесії входу. maxAge — абсолютний строк сесії: оновлення токенів
+// її не продовжує, після нього потрібен новий вхід.
+type SessionsService struct {
+	repo       domain.SessionRepository
+	usedTokens domain.UsedTokenRepository
+	maxAge     time.Duration
+}
+
+func NewSessionsService(repo domain.SessionRepository, usedTokens domain.UsedTokenRepository, maxAge time.Duration) *SessionsService {
+	return &SessionsService{repo: repo, usedTokens: usedTokens, maxAge: maxAge}
+}
+
+func (s *SessionsService) Start(ctx context.Context, userID int, deviceName, ip, userAgent string) (domain.Session, error) {
+	now := time.Now()
+
+	session := domain.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		DeviceName: deviceName,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	return session, s.repo.CreateSession(ctx, session)
+}
+
+// Validate перевіряє, що сесія належить користувачу і не відкликана.
+func (s *SessionsService) Validate(ctx context.Context, sessionID string, userID int) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != userID {
+		return domain.NewError(domain.ErrUnauthorized, "session belongs to another user")
+	}
+	if session.RevokedAt != nil {
+		return domain.NewError(domain.ErrUnauthorized, "session revoked")
+	}
+
+	if time.Since(session.LastSeenAt) > touchInterval {
+		return s.repo.TouchSession(ctx, sessionID, "", "", time.Now())
+	}
+
+	return nil
+}
+
+// Refresh продовжує сесію при оновленні токена, якщо не минув її абсолютний строк.
+func (s *SessionsService) Refresh(ctx context.Context, sessionID string, userID int, ip, userAgent string) error {
+	if err := s.Validate(ctx, sessionID, userID); err != nil {
+		return err
+	}
+
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if time.Since(session.CreatedAt) > s.maxAge {
+		return domain.ErrSessionExpired
+	}
+
+	return s.repo.TouchSession(ctx, sessionID, ip, userAgent, time.Now())
+}
+
+// ConsumeRefresh погашає токен оновлення браузерної сесії: кожен токен діє один раз,
+// а на заміну видається новий. Повторне пред'явлення погашеного токена означає, що його
+// скопійовано, тому сесію відкликано для обох власників.
+func (s *SessionsService) ConsumeRefresh(ctx context.Context, token string, refresh *auth.ActionToken) error {
+	sum := sha256.Sum256([]byte(token))
+	first, err := s.usedTokens.MarkUsed(ctx, auth.PurposeRefresh+":"+hex.EncodeToString(sum[:]), time.Unix(refresh.ExpiresAt, 0))
+	if err != nil {
+		return err
+	}
+	if first {
+		return nil
+	}
+
+	if err := s.Revoke(ctx, refresh.UserID, refresh.SessionID); err != nil && !errors.Is(err, domain.ErrSessionNotFound) {
+		return err
+	}
+	return domain.ErrRefreshReused
+}
+
+func (s *SessionsService) List(ctx context.Context, userID int, currentSessionID string) ([]domain.Session, error) {
+	sessions, err := s.repo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionsService) Revoke(ctx context.Context, userID int, sessionID string) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil {
+		return domain.ErrSessionNotFound
+	}
+
+	return s.repo.RevokeSession(ctx, sessionID)
+}
+
+func (s *SessionsService) RevokeAll(ctx context.Context, userID int) error {
+	return s.repo.RevokeUserSessions(ctx, userID)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sso-service/internal/domain"
+	"sso-service/pkg/auth"
+	"time"
+)
+
+// TokenExchangeService видає сервісам похідні токени з вужчими audience та scope (RFC 8693),
+// щоб кожна ланка виклику мала мінімально необхідні права.
+type TokenExchangeService struct {
+	tokenTTL time.Duration
+}
+
+func NewTokenExchangeService(tokenTTL time.Duration) *TokenExchangeService {
+	return &TokenExchangeService{tokenTTL: tokenTTL}
+}
+
+// Exchange перевіряє subject token і повертає claims нового токена для client.
+func (s *TokenExchangeService) Exchange(ctx context.Context, client domain.OAuthClient, req domain.TokenExchangeRequest) (auth.JWTToken, error) {
+	if req.SubjectToken == "" {
+		return auth.JWTToken{}, domain.ErrInvalidRequest
+	}
+	if req.SubjectTokenType != domain.TokenTypeAccessToken && req.SubjectTokenType != domain.TokenTypeJWT {
+		return auth.JWTToken{}, domain.ErrInvalidRequest
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != domain.TokenTypeAccessToken {
+		return auth.JWTToken{}, domain.ErrInvalidRequest
+	}
+
+	if req.Audience == "" || !slices.Contains(client.Audiences, req.Audience) {
+		return auth.JWTToken{}, domain.ErrInvalidTarget
+	}
+
+	// Токен, виданий для іншого сервісу, може обміняти лише сам цей сервіс.
+	subject, err := auth.ParseTokenFor(req.SubjectToken, client.ClientID)
+	if err != nil {
+		return auth.JWTToken{}, domain.ErrInvalidGrant
+	}
+	if err := auth.CheckToken(ctx, subject); err != nil {
+		return auth.JWTToken{}, domain.ErrInvalidGrant
+	}
+
+	scopes, err := narrowScopes(subject.Scope, domain.ParseScope(req.Scope), client)
+	if err != nil {
+		return auth.JWTToken{}, err
+	}
+
+	// Ролі користувача не переносяться: похідний токен діє лише в межах звуженого scope.
+	claims := auth.JWTToken{
+		Username:  subject.Username,
+		UserID:    subject.UserID,
+		SessionID: subject.SessionID,
+		ClientID:  subject.ClientID,
+		Scope:     domain.JoinScopes(scopes),
+		OrgID:     subject.OrgID,
+		Act:       &auth.Actor{Subject: client.ClientID, ClientID: client.ClientID, Act: subject.Act},
+	}
+	claims.CopyAuthContext(subject)
+	claims.Audience = req.Audience
+	claims.Subject = subject.Subject
+
+	// Похідний токен не переживає вихідний.
+	claims.ExpiresAt = time.Now().Add(s.tokenTTL).Unix()
+	if subject.ExpiresAt < claims.ExpiresAt {
+		claims.ExpiresAt = subject.ExpiresAt
+	}
+
+	return claims, nil
+}
+
+// narrowScopes дозволяє лише звуження: кожен запитаний дозвіл має бути і у вихідному токені
+// (якщо той обмежений), і серед дозволених клієнту. Без scope нового токена не буде.
+func narrowScopes(subjectScope string, requested []string, client domain.OAuthClient) ([]string, error) {
+	if len(requested) == 0 {
+		if subjectScope == "" {
+			return nil, domain.ErrInvalidScope
+		}
+		requested = domain.ParseScope(subjectScope)
+	}
+
+	subjectScopes := domain.ParseScope(subjectScope)
+	for _, scope := range requested {
+		if _, ok := domain.LookupScope(scope); !ok {
+			return nil, domain.ErrInvalidScope
+		}
+		if subjectScope != "" && !slices.Contains(subjectScopes, scope) {
+			return nil, domain.ErrInvalidScope
+		}
+		if len(client.Scopes) > 0 && !slices.Contains(client.Scopes, scope) {
+			return nil, domain.ErrInvalidScope
+		}
+	}
+
+	return requested, nil
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,15 +19,19 @@ import (
 	"github.com/google/uuid"
 )
 
+const passwordResetTTL = time.Hour
+
 type UsersService struct {
 	repo              domain.UserRepository
+	notifications     *NotificationsService
 	storageServiceURL string
 	httpClient        http.Client
 }
 
-func NewUsersService(repo domain.UserRepository, storageServiceURL string) *UsersService {
+func NewUsersService(repo domain.UserRepository, notifications *NotificationsService, storageServiceURL string) *UsersService {
 	return &UsersService{
 		repo:              repo,
+		notifications:     notifications,
 		storageServiceURL: storageServiceURL,
 		httpClient:        http.Client{Timeout: 10 * time.Second},
 	}
@@ -108,13 +114,24 @@ func (s *UsersService) DeleteAvatar(filename string) error {
 }
 
 func (s *UsersService) CreateUser(ctx context.Context, req *domain.RegisterRequest) error {
-	exists, err := s.repo.ExistsByEmail(ctx, req.Email)
-	if err != nil {
+	return s.createUser(ctx, req, "user")
+}
+
+// CreateAdmin створює адміністратора. Доступно лише з CLI, не через HTTP API.
+func (s *UsersService) CreateAdmin(ctx context.Context, req *domain.RegisterRequest) error {
+	return s.createUser(ctx, req, "admin")
+}
+
+// createUser перевіряє поля тут, а не в обробнику, щоб реєстрація і CLI застосовували однакові правила.
+// Зайнятість email і логіна заздалегідь не перевіряється: конфлікт виявляє сама вставка,
+// тому дві одночасні реєстрації не можуть обидві пройти.
+func (s *UsersService) createUser(ctx context.Context, req *domain.RegisterRequest, role string) error {
+	if err := req.Validate(); err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("user already exists")
-	}
+
+	req.Email = domain.NormalizeEmail(req.Email)
+	req.Login = domain.NormalizeLogin(req.Login)
 
 	hashedPwd, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -124,7 +141,7 @@ func (s *UsersService) CreateUser(ctx context.Context, req *domain.RegisterReque
 	user := domain.User{
 		Login:        req.Login,
 		Email:        req.Email,
-		Role:         "user",
+		Role:         role,
 		HashPassword: hashedPwd,
 		Address:      req.Address,
 		Phonenumber:  req.Phonenumber,
@@ -135,33 +152,309 @@ func (s *UsersService) CreateUser(ctx context.Context, req *domain.RegisterReque
 	userID, err := s.repo.CreateUser(ctx, user)
 
 	req.UserID = userID
+	req.Role = user.Role
 	return err
 }
 
 func (s *UsersService) GetByEmail(ctx context.Context, email string) (domain.User, error) {
-	return s.repo.GetByEmail(ctx, email)
+	return s.repo.GetByEmail(ctx, domain.NormalizeEmail(email))
 }
 
 func (s *UsersService) ExistsByEmail(ctx context.Context, email string) (bool, error) {
-	return s.repo.ExistsByEmail(ctx, email)
+	return s.repo.ExistsByEmail(ctx, domain.NormalizeEmail(email))
 }
 
 func (s *UsersService) GetByUsername(ctx context.Context, username string) (domain.User, error) {
-	return s.repo.GetByUsername(ctx, username)
+	return s.repo.GetByUsername(ctx, domain.NormalizeLogin(username))
 }
 
 func (s *UsersService) ExistsByUsername(ctx context.Context, username string) (bool, error) {
-	return s.repo.ExistsByUsername(ctx, username)
+	return s.repo.ExistsByUsername(ctx, domain.NormalizeLogin(username))
 }
 
-func (s *UsersService) UpdateUserProfile(ctx context.Context, userData domain.UserUpdateRequest) error {
-	hashPassword, err := auth.HashPassword(userData.Password)
+// UpdateUserProfile перевіряє поля (як і createUser — незалежно від обробника), оновлює профіль
+// і повертає назви змінених полів. Зміна пароля позначається полем "Password"; порожній пароль лишає поточний.
+func (s *UsersService) UpdateUserProfile(ctx context.Context, userData domain.UserUpdateRequest) ([]string, error) {
+	if err := userData.Validate(); err != nil {
+		return nil, err
+	}
+
+	userData.Email = domain.NormalizeEmail(userData.Email)
+	userData.Login = domain.NormalizeLogin(userData.Login)
+
+	current, err := s.repo.GetByID(ctx, userData.UserID)
 	if err != nil {
-		slog.Debug("Ошибка при хешировании пароля:", "err", err.Error())
+		return nil, err
+	}
+
+	userData.HashPassword = current.HashPassword
+	if userData.Password != "" {
+		hashPassword, err := auth.HashPassword(userData.Password)
+		if err != nil {
+			slog.DebugContext(ctx, "Помилка при хешуванні пароля", "err", err.Error())
+			return nil, err
+		}
+		userData.HashPassword = hashPassword
+	}
+
+	if err := s.repo.UpdateUserProfile(ctx, userData); err != nil {
+		return nil, err
+	}
+
+	return changedFields(current, userData), nil
+}
+
+func changedFields(current domain.User, update domain.UserUpdateRequest) []string {
+	var changed []string
+
+	compare := func(field, old, new string) {
+		if old != new {
+			changed = append(changed, field)
+		}
+	}
+
+	compare("Login", current.Login, update.Login)
+	compare("FirstName", current.FirstName, update.FirstName)
+	compare("LastName", current.LastName, update.LastName)
+	compare("Email", current.Email, update.Email)
+	compare("Phonenumber", current.Phonenumber, update.Phonenumber)
+	compare("Address", current.Address, update.Address)
+	if update.AvatarPath != "" {
+		compare("AvatarPath", current.AvatarPath, update.AvatarPath)
+	}
+	if update.Password != "" && auth.CheckPassword(current.HashPassword, update.Password) != nil {
+		changed = append(changed, "Password")
+	}
+
+	return changed
+}
+
+// checkPassword перевіряє пароль користувача і повертає domain.ErrInvalidPassword, якщо він не збігається.
+func checkPassword(user domain.User, password string) error {
+	if auth.CheckPassword(user.HashPassword, password) != nil {
+		return domain.ErrInvalidPassword
+	}
+	return nil
+}
+
+func (s *UsersService) GetByID(ctx context.Context, userID int) (domain.User, error) {
+	return s.repo.GetByID(ctx, userID)
+}
+
+// EnsureActive перевіряє, що статус користувача дозволяє автентифікацію.
+// Прострочене блокування знімається автоматично.
+func (s *UsersService) EnsureActive(ctx context.Context, user *domain.User) error {
+	status := user.EffectiveStatus(time.Now())
+
+	if status == domain.UserStatusActive && user.Status == domain.UserStatusSuspended {
+		if err := s.repo.UpdateUserStatus(ctx, user.UserID, domain.UserStatusActive, "", nil); err != nil {
+			return err
+		}
+		user.Status, user.StatusReason, user.StatusUntil = domain.UserStatusActive, "", nil
+	}
+
+	if status != domain.UserStatusActive {
+		return &domain.UserStatusError{Status: status, Reason: user.StatusReason, Until: user.StatusUntil}
+	}
+
+	return nil
+}
+
+func (s *UsersService) CheckUserStatus(ctx context.Context, userID int) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.EnsureActive(ctx, &user)
+}
+
+func (s *UsersService) Deactivate(ctx context.Context, userID int, password string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPassword(user, password); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateUserStatus(ctx, userID, domain.UserStatusDeactivated, "", nil)
+}
+
+// VerifyPassword перевіряє поточний пароль користувача.
+func (s *UsersService) VerifyPassword(ctx context.Context, userID int, password string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return checkPassword(user, password)
+}
+
+// SetPassword встановлює новий пароль без перевірки старого і знімає вимогу скидання.
+func (s *UsersService) SetPassword(ctx context.Context, userID int, password string) error {
+	if err := domain.ValidatePassword(password); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
 		return err
 	}
 
-	userData.HashPassword = hashPassword
+	hashPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePassword(ctx, userID, hashPassword)
+}
+
+// ImpersonationTarget перевіряє, що адміністратор може діяти від імені користувача:
+// не від себе, не від іншого адміністратора і лише для активного облікового запису.
+func (s *UsersService) ImpersonationTarget(ctx context.Context, adminID, targetID int) (domain.User, error) {
+	if adminID == targetID {
+		return domain.User{}, domain.NewError(domain.ErrForbidden, "cannot impersonate yourself")
+	}
+
+	user, err := s.repo.GetByID(ctx, targetID)
+	if err != nil {
+		return user, err
+	}
+
+	if user.Role == "admin" {
+		return user, domain.NewError(domain.ErrForbidden, "cannot impersonate another admin")
+	}
+
+	if err := s.EnsureActive(ctx, &user); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (s *UsersService) Reactivate(ctx context.Context, email, password string) (domain.User, error) {
+	user, err := s.GetByEmail(ctx, email)
+	if err != nil {
+		return user, err
+	}
+
+	if err := checkPassword(user, password); err != nil {
+		return user, err
+	}
+
+	if user.Status == domain.UserStatusDeactivated {
+		if err := s.repo.UpdateUserStatus(ctx, user.UserID, domain.UserStatusActive, "", nil); err != nil {
+			return user, err
+		}
+		user.Status = domain.UserStatusActive
+	}
+
+	return user, s.EnsureActive(ctx, &user)
+}
+
+// Suspend блокує користувача. Як і з імперсонацією, адміністратор не може заблокувати себе
+// чи іншого адміністратора: інакше можна лишити сервіс без жодного адміністратора.
+// adminID 0 — дія з CLI, без актора.
+func (s *UsersService) Suspend(ctx context.Context, adminID, userID int, reason string, until *time.Time) error {
+	if until != nil && !until.After(time.Now()) {
+		return domain.NewValidationError("until", domain.RuleNotInFuture, nil)
+	}
+
+	if adminID == userID {
+		return domain.NewError(domain.ErrForbidden, "cannot suspend yourself")
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Role == "admin" {
+		return domain.NewError(domain.ErrForbidden, "cannot suspend an admin")
+	}
+
+	return s.repo.UpdateUserStatus(ctx, userID, domain.UserStatusSuspended, reason, until)
+}
+
+func (s *UsersService) Unsuspend(ctx context.Context, userID int) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Status != domain.UserStatusSuspended {
+		return domain.NewError(domain.ErrConflict, "user is not suspended")
+	}
+
+	return s.repo.UpdateUserStatus(ctx, userID, domain.UserStatusActive, "", nil)
+}
+
+// passwordVersion прив'язує токен скидання до поточного хешу, тож після зміни пароля токен стає недійсним.
+func passwordVersion(hashPassword string) string {
+	sum := sha256.Sum256([]byte(hashPassword))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ForcePasswordReset блокує вхід за паролем до його зміни і надсилає лист зі скиданням.
+func (s *UsersService) ForcePasswordReset(ctx context.Context, userID int) error {
+	if err := s.repo.SetPasswordResetRequired(ctx, userID, true); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.sendPasswordReset(ctx, user)
+}
+
+func (s *UsersService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	return s.sendPasswordReset(ctx, user)
+}
+
+func (s *UsersService) sendPasswordReset(ctx context.Context, user domain.User) error {
+	token, err := auth.IssueActionToken(auth.ActionToken{
+		Purpose: auth.PurposePasswordReset,
+		UserID:  user.UserID,
+		Version: passwordVersion(user.HashPassword),
+	}, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.notifications.PasswordReset(ctx, user, token)
+}
+
+// ResetPassword встановлює новий пароль за токеном з листа і повертає ID користувача.
+func (s *UsersService) ResetPassword(ctx context.Context, token, newPassword string) (int, error) {
+	if err := domain.ValidatePassword(newPassword); err != nil {
+		return 0, err
+	}
+
+	claims, err := auth.ParseActionToken(token, auth.PurposePasswordReset)
+	if err != nil {
+		return 0, domain.NewError(domain.ErrUnauthorized, "invalid password reset token: %v", err)
+	}
+
+	user, err := s.repo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	if claims.Version != passwordVersion(user.HashPassword) {
+		return 0, domain.NewError(domain.ErrUnauthorized, "password reset token already used")
+	}
+
+	hashPassword, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return 0, err
+	}
 
-	return s.repo.UpdateUserProfile(ctx, userData)
+	return user.UserID, s.repo.UpdatePassword(ctx, user.UserID, hashPassword)
 }
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Призначення одноразових токенів, що надсилаються в листах.
+// Такі токени не приймаються як токени доступу.
+const (
+	PurposeReportLogin   = "report_login"
+	PurposePasswordReset = "password_reset"
+	PurposeOAuthState    = "oauth_state"
+	// PurposeRefresh — довгоживучий токен оновлення браузерної сесії в HttpOnly cookie.
+	PurposeRefresh = "refresh"
+)
+
+// ActionToken — токен для посилань у листах (звіт про підозрілий вхід, скидання пароля)
+// та параметра state у вході через зовнішніх провайдерів.
+// Version прив'язує токен до стану користувача, наприклад до поточного хешу пароля.
+type ActionToken struct {
+	Purpose   string `json:"purpose"`
+	UserID    int    `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`
+	Version   string `json:"ver,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
+	// AuthTime та AMR токена оновлення переносяться в нові токени доступу.
+	AuthTime int64    `json:"auth_time,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	jwt.StandardClaims
+}
+
+func IssueActionToken(claims ActionToken, ttl time.Duration) (string, error) {
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	claims.Issuer = TokenIssuer
+
+	tk, err := signClaims(&claims)
+	if err != nil {
+		return "", fmt.Errorf("could not create action token: %v", err)
+	}
+
+	return tk, nil
+}
+
+func ParseActionToken(tokenStr, purpose string) (*ActionToken, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &ActionToken{}, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*ActionToken)
+	if !ok || !token.Valid || claims.Purpose != purpose {
+		return nil, fmt.Errorf("invalid action token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+// Cookie браузерних сесій. Токени лежать у HttpOnly cookie, а CSRF-токен — у звичайній,
+// щоб фронтенд міг прочитати його й повернути в заголовку (double-submit).
+const (
+	AccessTokenCookie  = "carvia_access"
+	RefreshTokenCookie = "carvia_refresh"
+	CSRFCookie         = "carvia_csrf"
+	CSRFHeader         = "X-CSRF-Token"
+	// OAuthStateCookie прив'язує state входу через зовнішнього провайдера до браузера, що його почав.
+	OAuthStateCookie = "carvia_oauth_state"
+)
+
+var cookieAuth bool
+
+// EnableCookieAuth дозволяє AuthMiddleware приймати токен з cookie, якщо заголовка Authorization немає.
+func EnableCookieAuth() {
+	cookieAuth = true
+}
+
+// requestToken повертає токен і ознаку, що його взято з cookie.
+func requestToken(r *http.Request) (string, bool, bool) {
+	if r.Header.Get("Authorization") != "" || !cookieAuth {
+		token, ok := bearerToken(r)
+		return token, false, ok
+	}
+
+	cookie, err := r.Cookie(AccessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false, false
+	}
+	return cookie.Value, true, true
+}
+
+// TokenFromRequest бере токен із заголовка Authorization, а якщо його немає — з cookie.
+// Для запитів з cookie, що змінюють стан, викличте VerifyCSRF.
+func TokenFromRequest(r *http.Request) (token string, fromCookie bool, ok bool) {
+	return requestToken(r)
+}
+
+// NewCSRFToken генерує значення для double-submit cookie.
+func NewCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// VerifyCSRF вимагає, щоб для методу, який змінює стан, заголовок X-CSRF-Token збігався з cookie.
+// method передається окремо, бо за forward-auth метод оригінального запиту приходить у заголовку.
+func VerifyCSRF(r *http.Request, method string) error {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return fmt.Errorf("CSRF cookie is missing")
+	}
+
+	header := r.Header.Get(CSRFHeader)
+	if header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+		return fmt.Errorf("CSRF token mismatch")
+	}
+
+	return nil
+}
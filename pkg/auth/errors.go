@@ -0,0 +1,35 @@
+package auth
+
+import "net/http"
+
+// Коди причин відмови, які middleware передають в ErrorWriter.
+const (
+	ErrCodeUnauthorized           = "unauthorized"
+	ErrCodeInvalidCSRFToken       = "invalid_csrf_token"
+	ErrCodeStepUpRequired         = "step_up_required"
+	ErrCodeImpersonationForbidden = "impersonation_forbidden"
+	ErrCodeInsufficientScope      = "insufficient_scope"
+)
+
+// ErrorWriter відповідає на відмову в доступі. code — одна з констант ErrCode*.
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, status int, code string)
+
+var errorWriter ErrorWriter = plainTextError
+
+// RegisterErrorWriter замінює відповідь middleware на відмову, напр. на JSON мовою клієнта.
+// Без неї відповідь — простий текст українською.
+func RegisterErrorWriter(fn ErrorWriter) {
+	errorWriter = fn
+}
+
+var plainTextMessages = map[string]string{
+	ErrCodeUnauthorized:           "Не авторизовано",
+	ErrCodeInvalidCSRFToken:       "Недійсний CSRF-токен",
+	ErrCodeStepUpRequired:         "Потрібна повторна автентифікація",
+	ErrCodeImpersonationForbidden: "Операція недоступна під час імперсонації",
+	ErrCodeInsufficientScope:      "Токен не має потрібних дозволів",
+}
+
+func plainTextError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	http.Error(w, plainTextMessages[code], status)
+}
@@ -8,29 +8,124 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
+// TokenIssuer — значення iss у токенах SSO.
+const TokenIssuer = "sso_service"
+
+// jwtKey підписував токени до переходу на RS256. Якщо JWT_SECRET задано,
+// токени HS256 ще приймаються, доки не спливуть.
 var jwtKey = []byte(os.Getenv("JWT_SECRET"))
 
+var signingKeys *KeySet
+
+// UseKeySet вмикає підпис RS256 ключами SSO. Без нього токени підписуються HS256 з JWT_SECRET.
+func UseKeySet(ks *KeySet) {
+	signingKeys = ks
+}
+
+func signClaims(claims jwt.Claims) (string, error) {
+	if signingKeys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
+	}
+
+	key := signingKeys.Active()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Key)
+}
+
+func keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA:
+		if signingKeys == nil {
+			return nil, fmt.Errorf("signing keys are not configured")
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := signingKeys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	case *jwt.SigningMethodHMAC:
+		if len(jwtKey) == 0 {
+			return nil, fmt.Errorf("HS256 tokens are not accepted")
+		}
+		return jwtKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+}
+
 type JWTToken struct {
-	Username string `json:"username"`
-	UserID   int    `json:"user_id"`
+	Username  string `json:"username"`
+	UserID    int    `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`
+	// Roles — ролі на момент видачі токена; для авторизації адмін-дій роль перевіряється по БД.
+	Roles []string `json:"roles,omitempty"`
+	// ClientID — OAuth-клієнт, якому видано токен; порожній для власних застосунків CarVia.
+	ClientID string `json:"client_id,omitempty"`
+	// Scope — дозволи через пробіл; порожній означає повний доступ власного застосунку.
+	Scope string `json:"scope,omitempty"`
+	OrgID int    `json:"org_id,omitempty"`
+	// AuthTime, AMR та ACR описують, як і коли користувач востаннє підтвердив особу
+	// (OIDC Core, RFC 8176). Оновлюються при вході та повторній автентифікації, але не при refresh.
+	AuthTime int64    `json:"auth_time,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	ACR      string   `json:"acr,omitempty"`
+	// Act — хто діє від імені користувача (RFC 8693): адміністратор при імперсонації
+	// або сервіс при обміні токена. Вкладений Act описує попередню ланку.
+	Act *Actor `json:"act,omitempty"`
+	// AuthMethod — "jwt" або значення, яке задає OpaqueTokenResolver (напр. "pat"). У токен не серіалізується.
+	AuthMethod string `json:"-"`
+	// Purpose заповнений лише в ActionToken; токен доступу з ним недійсний.
+	Purpose string `json:"purpose,omitempty"`
 	jwt.StandardClaims
 }
 
-func CreateToken(username string, userID int, tokenTTL time.Duration) (string, error) {
-	expirationTime := time.Now().Add(tokenTTL).Add(time.Hour).Unix()
-
-	claims := &JWTToken{
-		Username: username,
-		UserID:   userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime,
-			Issuer:    "sso_service",
-		},
+type Actor struct {
+	Subject  string `json:"sub"`
+	Username string `json:"username,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Act      *Actor `json:"act,omitempty"`
+}
+
+// Impersonator повертає адміністратора з ланцюжка act. Ланцюжок переглядається повністю:
+// токен, обміняний сервісом, лишається токеном імперсонації.
+func (t *JWTToken) Impersonator() (*Actor, bool) {
+	for actor := t.Act; actor != nil; actor = actor.Act {
+		if actor.ClientID == "" {
+			return actor, true
+		}
+	}
+	return nil, false
+}
+
+// Delegated — токен діє від імені користувача, але виданий не йому самому в застосунку CarVia:
+// персональний токен, токен OAuth-клієнта або токен, обміняний сервісом. Такі токени обмежені
+// своїми scope і приймаються лише маршрутами з RequireScope.
+func (t *JWTToken) Delegated() bool {
+	if t.AuthMethod == "pat" || t.Scope != "" || t.ClientID != "" {
+		return true
+	}
+	for actor := t.Act; actor != nil; actor = actor.Act {
+		if actor.ClientID != "" {
+			return true
+		}
 	}
+	return false
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+func CreateToken(username string, userID int, tokenTTL time.Duration) (string, error) {
+	return IssueToken(JWTToken{Username: username, UserID: userID}, tokenTTL)
+}
 
-	tk, err := token.SignedString(jwtKey)
+// IssueToken підписує claims, виставляючи термін дії та issuer.
+// Якщо ExpiresAt уже заданий (короткоживучі токени), tokenTTL ігнорується.
+func IssueToken(claims JWTToken, tokenTTL time.Duration) (string, error) {
+	if claims.ExpiresAt == 0 {
+		claims.ExpiresAt = time.Now().Add(tokenTTL).Add(time.Hour).Unix()
+	}
+	claims.Issuer = TokenIssuer
+
+	tk, err := signClaims(&claims)
 	if err != nil {
 		return "", fmt.Errorf("could not create token: %v", err)
 	}
@@ -38,26 +133,37 @@ func CreateToken(username string, userID int, tokenTTL time.Duration) (string, e
 	return tk, nil
 }
 
+// ParseToken приймає лише токени для самого SSO: без aud або з aud = TokenIssuer.
+// Токен, звужений обміном до іншого сервісу, тут недійсний.
 func ParseToken(tokenStr string) (*JWTToken, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTToken{}, func(token *jwt.Token) (any, error) {
-		return jwtKey, nil
-	})
+	return ParseTokenFor(tokenStr, "")
+}
+
+// ParseTokenFor приймає ще й токени, звужені до audience (ідентифікатора сервісу).
+func ParseTokenFor(tokenStr, audience string) (*JWTToken, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &JWTToken{}, keyFunc)
 	if err != nil {
 		return nil, err
 	}
 
 	claims, ok := token.Claims.(*JWTToken)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.Purpose != "" {
 		return nil, fmt.Errorf("invalid token")
 	}
+	if !claims.IntendedFor(audience) {
+		return nil, fmt.Errorf("token audience %q is not accepted here", claims.Audience)
+	}
 
 	return claims, nil
 }
 
+// IntendedFor — токен призначений для SSO (aud порожній або TokenIssuer) чи для сервісу audience.
+func (t *JWTToken) IntendedFor(audience string) bool {
+	return t.Audience == "" || t.Audience == TokenIssuer || (audience != "" && t.Audience == audience)
+}
+
 func UserIDFromToken(tokenString string) (int, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return jwtKey, nil
-	})
+	token, err := jwt.Parse(tokenString, keyFunc)
 
 	if err != nil || !token.Valid {
 		return 0, fmt.Errorf("invalid token")
@@ -68,6 +174,10 @@ func UserIDFromToken(tokenString string) (int, error) {
 		return 0, fmt.Errorf("cannot parse token claims")
 	}
 
+	if _, isAction := claims["purpose"]; isAction {
+		return 0, fmt.Errorf("invalid token")
+	}
+
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
 		return 0, fmt.Errorf("user_id not found in token")
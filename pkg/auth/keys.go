@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signingKeyBits = 2048
+	keyIDLayout    = "20060102T150405Z"
+)
+
+// ErrNoSigningKeys — у каталозі немає жодного ключа. Перший ключ створює "app keys generate":
+// якби кожна репліка генерувала свій, токени однієї не проходили б перевірку в інших.
+var ErrNoSigningKeys = errors.New("no signing keys")
+
+// SigningKey — RSA-ключ підпису токенів. ID потрапляє в заголовок kid і в JWKS.
+type SigningKey struct {
+	ID  string
+	Key *rsa.PrivateKey
+}
+
+// KeySet — ключі підпису SSO. Активний (найновіший) підписує нові токени,
+// попередні лишаються в JWKS, доки не спливуть видані ними токени.
+type KeySet struct {
+	keys []SigningKey
+}
+
+// NewKeyID формує kid з моменту створення, тож сортування kid збігається з порядком ротації.
+func NewKeyID(now time.Time) string {
+	return now.UTC().Format(keyIDLayout)
+}
+
+// GenerateSigningKey створює новий ключ і зберігає його в dir як <kid>.pem.
+// kid має точність до секунди, тож другий ключ у ту саму секунду не перезаписує перший,
+// а повертає помилку з os.ErrExist: інакше токени, підписані першим, перестали б перевірятися.
+func GenerateSigningKey(dir string) (SigningKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, signingKeyBits)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return SigningKey{}, err
+	}
+
+	signingKey := SigningKey{ID: NewKeyID(time.Now()), Key: key}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+
+	path := filepath.Join(dir, signingKey.ID+".pem")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("signing key %s: %w", signingKey.ID, err)
+	}
+	if _, err := f.Write(pem.EncodeToMemory(block)); err != nil {
+		f.Close()
+		os.Remove(path)
+		return SigningKey{}, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return SigningKey{}, err
+	}
+
+	return signingKey, nil
+}
+
+// LoadKeySet читає ключі з dir. Ключі, виведені з обігу (див. retired), не завантажуються
+// і не публікуються в JWKS, навіть якщо їхні файли ще не видалено.
+func LoadKeySet(dir string, retention time.Duration) (*KeySet, error) {
+	keys, err := readSigningKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	live := make([]SigningKey, 0, len(keys))
+	for i, key := range keys {
+		if !retired(keys, i, now, retention) {
+			live = append(live, key)
+		}
+	}
+
+	return &KeySet{keys: live}, nil
+}
+
+// PruneSigningKeys видаляє з dir файли ключів, виведених з обігу, і повертає їхні kid.
+func PruneSigningKeys(dir string, retention time.Duration) ([]string, error) {
+	keys, err := readSigningKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var removed []string
+	for i, key := range keys {
+		if !retired(keys, i, now, retention) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, key.ID+".pem")); err != nil {
+			return removed, err
+		}
+		removed = append(removed, key.ID)
+	}
+
+	return removed, nil
+}
+
+// retired — ключ keys[i] замінено наступним понад retention тому, тож усе, що він підписав, уже спливло.
+// Активний ключ і ключі з kid не у форматі NewKeyID не виводяться з обігу.
+func retired(keys []SigningKey, i int, now time.Time, retention time.Duration) bool {
+	if i == len(keys)-1 {
+		return false
+	}
+	if _, err := time.Parse(keyIDLayout, keys[i].ID); err != nil {
+		return false
+	}
+	replacedAt, err := time.Parse(keyIDLayout, keys[i+1].ID)
+	if err != nil {
+		return false
+	}
+	return now.Sub(replacedAt) > retention
+}
+
+// readSigningKeys читає всі ключі з dir у порядку ротації.
+func readSigningKeys(dir string) ([]SigningKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoSigningKeys, dir)
+	}
+
+	keys := make([]SigningKey, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("invalid PEM in %s", path)
+		}
+
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %s: %v", path, err)
+		}
+
+		keys = append(keys, SigningKey{ID: strings.TrimSuffix(filepath.Base(path), ".pem"), Key: key})
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys, nil
+}
+
+// Active повертає ключ, яким підписуються нові токени.
+func (ks *KeySet) Active() SigningKey {
+	return ks.keys[len(ks.keys)-1]
+}
+
+func (ks *KeySet) Lookup(kid string) (*rsa.PublicKey, bool) {
+	for _, key := range ks.keys {
+		if key.ID == kid {
+			return &key.Key.PublicKey, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS — публічні ключі для /.well-known/jwks.json.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		set.Keys = append(set.Keys, NewJWK(key.ID, &key.Key.PublicKey))
+	}
+	return set
+}
+
+// JWK — публічний RSA-ключ у форматі RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// PublicKey відновлює RSA-ключ з JWK.
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %v", err)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
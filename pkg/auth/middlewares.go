@@ -7,52 +7,203 @@ import (
 	"strings"
 )
 
-func AuthMiddleware(next func(w http.ResponseWriter, r *http.Request)) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// TokenCheck перевіряє стан, який не зберігається в самому токені
+// (статус користувача, відкликані сесії тощо). Виконується після перевірки підпису.
+type TokenCheck func(ctx context.Context, token *JWTToken) error
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			slog.Debug("Заголовок авторизації відсутній", "Authorization", authHeader)
-			http.Error(w, "Не авторизовано", http.StatusUnauthorized)
-			return
+var tokenChecks []TokenCheck
+
+func RegisterTokenCheck(check TokenCheck) {
+	tokenChecks = append(tokenChecks, check)
+}
+
+// CheckToken виконує зареєстровані перевірки для вже розібраного токена.
+func CheckToken(ctx context.Context, token *JWTToken) error {
+	for _, check := range tokenChecks {
+		if err := check(ctx, token); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+// OpaqueTokenResolver перетворює непрозорий токен (напр. персональний токен доступу) на claims.
+type OpaqueTokenResolver func(ctx context.Context, token string) (*JWTToken, error)
 
-		token, err := ParseToken(tokenString)
-		if err != nil {
-			slog.Debug("Помилка авторизації", "err", err.Error())
-			http.Error(w, "Не авторизовано", http.StatusUnauthorized)
-			return
+var opaqueResolvers = map[string]OpaqueTokenResolver{}
+
+// RegisterOpaqueTokenResolver приймає токени з указаним префіксом замість JWT.
+func RegisterOpaqueTokenResolver(prefix string, resolver OpaqueTokenResolver) {
+	opaqueResolvers[prefix] = resolver
+}
+
+// AuthenticatedHook отримує контекст запиту і суб'єкта після успішної автентифікації в AuthMiddleware,
+// напр. щоб журнал запитів знав user_id.
+type AuthenticatedHook func(ctx context.Context, principal *Principal)
+
+var authenticatedHooks []AuthenticatedHook
+
+func RegisterAuthenticatedHook(hook AuthenticatedHook) {
+	authenticatedHooks = append(authenticatedHooks, hook)
+}
+
+type tokenContextKey struct{}
+
+// TokenFromContext повертає claims токена, яким автентифіковано запит.
+func TokenFromContext(ctx context.Context) (*JWTToken, bool) {
+	token, ok := ctx.Value(tokenContextKey{}).(*JWTToken)
+	return token, ok
+}
+
+// ImpersonatorFromContext повертає адміністратора, якщо запит виконується від імені іншого користувача.
+func ImpersonatorFromContext(ctx context.Context) (*Actor, bool) {
+	token, ok := TokenFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return token.Impersonator()
+}
+
+func resolveToken(ctx context.Context, tokenString, audience string) (*JWTToken, error) {
+	for prefix, resolver := range opaqueResolvers {
+		if strings.HasPrefix(tokenString, prefix) {
+			return resolver(ctx, tokenString)
 		}
+	}
 
-		ctx := context.WithValue(r.Context(), "user_id", token.UserID)
-		ctx = context.WithValue(ctx, "username", token.Username)
-		next(w, r.WithContext(ctx))
-	})
+	token, err := ParseTokenFor(tokenString, audience)
+	if err != nil {
+		return nil, err
+	}
+	token.AuthMethod = "jwt"
+	return token, nil
+}
+
+// ResolveToken розбирає токен доступу (JWT або непрозорий) і виконує зареєстровані перевірки.
+// Приймаються лише токени для самого SSO (див. ParseToken).
+func ResolveToken(ctx context.Context, tokenString string) (*JWTToken, error) {
+	return ResolveTokenFor(ctx, tokenString, "")
+}
+
+// ResolveTokenFor — ResolveToken, що приймає ще й токени, звужені до сервісу audience.
+func ResolveTokenFor(ctx context.Context, tokenString, audience string) (*JWTToken, error) {
+	token, err := resolveToken(ctx, tokenString, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
 }
 
+func bearerToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		slog.DebugContext(r.Context(), "Заголовок авторизації відсутній", "Authorization", authHeader)
+		return "", false
+	}
+
+	return strings.TrimPrefix(authHeader, "Bearer "), true
+}
+
+func withToken(r *http.Request, token *JWTToken) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenContextKey{}, token)
+	ctx = WithPrincipal(ctx, NewPrincipal(token))
+	return r.WithContext(ctx)
+}
+
+func AuthMiddleware(next func(w http.ResponseWriter, r *http.Request)) http.Handler {
+	return AuthMiddlewareHandler(http.HandlerFunc(next))
+}
+
+// AuthMiddlewareHandler пускає лише інтерактивні токени користувача. Делегований токен
+// (JWTToken.Delegated) отримує 403, якщо маршрут не відкрито для нього через RequireScope.
 func AuthMiddlewareHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return authenticate(next, false, nil)
+}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			slog.Debug("Заголовок авторизації відсутній", "Authorization", authHeader)
-			http.Error(w, "Не авторизовано", http.StatusUnauthorized)
+// RequireScope — AuthMiddleware для маршрутів, відкритих делегованим токенам. Такий токен має містити
+// всі scopes; без scopes маршрут приймає будь-який делегований токен і сам обмежує відповідь (як /userinfo).
+// Інтерактивні токени проходять без перевірки дозволів.
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authenticate(next, true, scopes)
+	}
+}
+
+func authenticate(next http.Handler, allowDelegated bool, scopes []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, fromCookie, ok := requestToken(r)
+		if !ok {
+			errorWriter(w, r, http.StatusUnauthorized, ErrCodeUnauthorized)
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if fromCookie {
+			if err := VerifyCSRF(r, r.Method); err != nil {
+				slog.DebugContext(r.Context(), "Запит з cookie без CSRF-токена", "err", err.Error())
+				errorWriter(w, r, http.StatusForbidden, ErrCodeInvalidCSRFToken)
+				return
+			}
+		}
 
-		token, err := ParseToken(tokenString)
+		token, err := ResolveToken(r.Context(), tokenString)
 		if err != nil {
-			slog.Debug("Помилка авторизації", "err", err.Error())
-			http.Error(w, "Не авторизовано", http.StatusUnauthorized)
+			slog.DebugContext(r.Context(), "Помилка авторизації", "err", err.Error())
+			errorWriter(w, r, http.StatusUnauthorized, ErrCodeUnauthorized)
+			return
+		}
+
+		if token.Delegated() {
+			if !allowDelegated {
+				slog.DebugContext(r.Context(), "Делегований токен на маршруті лише для користувача",
+					"user_id", token.UserID, "auth_method", token.AuthMethod, "client_id", token.ClientID)
+				insufficientScope(w, r, "")
+				return
+			}
+			principal := NewPrincipal(token)
+			for _, scope := range scopes {
+				if !principal.HasScope(scope) {
+					slog.DebugContext(r.Context(), "Токену бракує дозволу", "user_id", token.UserID, "scope", scope)
+					insufficientScope(w, r, scope)
+					return
+				}
+			}
+		}
+
+		r = withToken(r, token)
+		if principal, ok := PrincipalFrom(r.Context()); ok {
+			for _, hook := range authenticatedHooks {
+				hook(r.Context(), principal)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// insufficientScope відповідає 403 з WWW-Authenticate: error="insufficient_scope" (RFC 6750).
+func insufficientScope(w http.ResponseWriter, r *http.Request, scope string) {
+	challenge := `Bearer error="insufficient_scope"`
+	if scope != "" {
+		challenge += `, scope="` + scope + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	errorWriter(w, r, http.StatusForbidden, ErrCodeInsufficientScope)
+}
+
+// DenyImpersonation блокує чутливі операції (зміна пароля, видалення, MFA) для токенів імперсонації.
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor, ok := ImpersonatorFromContext(r.Context()); ok {
+			slog.DebugContext(r.Context(), "Операцію заборонено під час імперсонації", "actor", actor.Subject)
+			errorWriter(w, r, http.StatusForbidden, ErrCodeImpersonationForbidden)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "user_id", token.UserID)
-		ctx = context.WithValue(ctx, "username", token.Username)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Principal — автентифікований суб'єкт запиту. Стабільний API для сервісів, що імпортують pkg/auth,
+// замість рядкових ключів контексту.
+type Principal struct {
+	UserID   int
+	Username string
+	Roles    []string
+	// Scopes порожній для токенів власних застосунків CarVia з повним доступом.
+	Scopes    []string
+	SessionID string
+	OrgID     int
+	ClientID  string
+	// AuthMethod — "jwt" або "pat".
+	AuthMethod string
+	AuthTime   time.Time
+	// Actor заповнений, якщо запит виконується від імені користувача (імперсонація, обмін токена).
+	Actor *Actor
+}
+
+type principalContextKey struct{}
+
+// NewPrincipal будує Principal з claims перевіреного токена.
+func NewPrincipal(token *JWTToken) *Principal {
+	principal := &Principal{
+		UserID:     token.UserID,
+		Username:   token.Username,
+		Roles:      token.Roles,
+		Scopes:     strings.Fields(token.Scope),
+		SessionID:  token.SessionID,
+		OrgID:      token.OrgID,
+		ClientID:   token.ClientID,
+		AuthMethod: token.AuthMethod,
+		Actor:      token.Act,
+	}
+	if token.AuthTime != 0 {
+		principal.AuthTime = time.Unix(token.AuthTime, 0)
+	}
+	return principal
+}
+
+// WithPrincipal кладе principal у контекст. Використовується AuthMiddleware та тестами.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFrom повертає суб'єкта, яким автентифіковано запит.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFrom — скорочення для найчастішого випадку.
+func UserIDFrom(ctx context.Context) (int, bool) {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserID, true
+}
+
+// SessionIDFrom повертає сесію, до якої належить токен запиту; порожній рядок — токен без сесії.
+func SessionIDFrom(ctx context.Context) string {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return ""
+	}
+	return principal.SessionID
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope вважає токен без scope повним доступом.
+func (p *Principal) HasScope(scope string) bool {
+	return len(p.Scopes) == 0 || slices.Contains(p.Scopes, scope)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Методи автентифікації (RFC 8176).
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+	// AMRFederated — вхід через зовнішнього провайдера (OAuth/OIDC, SAML).
+	AMRFederated = "fed"
+)
+
+// Рівні автентифікації для claim acr.
+const (
+	ACRBasic = "urn:carvia:acr:basic"
+	ACRMFA   = "urn:carvia:acr:mfa"
+)
+
+// SetAuthContext фіксує момент і методи автентифікації користувача.
+func (t *JWTToken) SetAuthContext(authTime time.Time, amr []string) {
+	t.AuthTime = authTime.Unix()
+	t.AMR = amr
+	t.ACR = ACRBasic
+	if slices.Contains(amr, AMRMFA) {
+		t.ACR = ACRMFA
+	}
+}
+
+// CopyAuthContext переносить auth_time, amr та acr з іншого токена (refresh, обмін токена).
+func (t *JWTToken) CopyAuthContext(from *JWTToken) {
+	t.AuthTime = from.AuthTime
+	t.AMR = from.AMR
+	t.ACR = from.ACR
+}
+
+// StepUpPolicy — вимоги до свіжості та сили автентифікації для чутливих дій.
+type StepUpPolicy struct {
+	// MaxAge — скільки часу після входу чи повторної автентифікації дія дозволена.
+	MaxAge time.Duration
+	// RequireMFA вимагає, щоб серед amr був "mfa".
+	RequireMFA bool
+}
+
+// RequireStepUp пропускає лише запити з достатньо свіжою автентифікацією. Інакше відповідає
+// 401 з WWW-Authenticate: error="insufficient_user_authentication" (RFC 9470),
+// і клієнт має провести користувача через /api/sso/reauthenticate.
+// Використовується після AuthMiddleware.
+func RequireStepUp(policy StepUpPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := TokenFromContext(r.Context())
+			if !ok {
+				errorWriter(w, r, http.StatusUnauthorized, ErrCodeUnauthorized)
+				return
+			}
+
+			authAge := time.Since(time.Unix(token.AuthTime, 0))
+			fresh := token.AuthTime != 0 && authAge <= policy.MaxAge
+			strong := !policy.RequireMFA || slices.Contains(token.AMR, AMRMFA)
+
+			if !fresh || !strong {
+				slog.DebugContext(r.Context(), "Потрібна повторна автентифікація", "user_id", token.UserID, "auth_time", token.AuthTime, "amr", token.AMR)
+
+				challenge := fmt.Sprintf(`Bearer error="insufficient_user_authentication", max_age=%d`, int(policy.MaxAge.Seconds()))
+				if policy.RequireMFA {
+					challenge += `, acr_values="` + ACRMFA + `"`
+				}
+				w.Header().Set("WWW-Authenticate", challenge)
+				errorWriter(w, r, http.StatusUnauthorized, ErrCodeStepUpRequired)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+// VerifierConfig налаштовує перевірку токенів SSO в інших сервісах CarVia.
+// Секрет SSO сервісам не потрібен: підписи перевіряються публічними ключами з JWKS.
+type VerifierConfig struct {
+	// JWKSURL, напр. https://sso.carvia.ua/api/sso/.well-known/jwks.json
+	JWKSURL string
+	// Issuer — очікуваний iss; за замовчуванням TokenIssuer.
+	Issuer string
+	// Audience — ідентифікатор сервісу. Токен з іншим aud відхиляється,
+	// без aud — приймається, лише якщо RequireAudience вимкнено.
+	Audience        string
+	RequireAudience bool
+	// ClockSkew — допустима розбіжність годинників для exp, nbf та iat.
+	ClockSkew time.Duration
+	// RefreshInterval — як часто JWKS оновлюється у фоні (15 хв за замовчуванням).
+	RefreshInterval time.Duration
+	// MinRefetchInterval обмежує позачергові запити JWKS, коли трапився невідомий kid (30 с).
+	MinRefetchInterval time.Duration
+	HTTPClient         *http.Client
+	// Introspection, якщо задано, перевіряє непрозорі токени (персональні токени доступу) через SSO.
+	Introspection *IntrospectionConfig
+}
+
+type IntrospectionConfig struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+}
+
+// IntrospectionResponse — відповідь /api/sso/introspect (RFC 7662) з полями CarVia.
+type IntrospectionResponse struct {
+	Active     bool     `json:"active"`
+	Subject    string   `json:"sub,omitempty"`
+	UserID     int      `json:"user_id,omitempty"`
+	Username   string   `json:"username,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+	ClientID   string   `json:"client_id,omitempty"`
+	SessionID  string   `json:"sid,omitempty"`
+	OrgID      int      `json:"org_id,omitempty"`
+	AuthMethod string   `json:"auth_method,omitempty"`
+	Issuer     string   `json:"iss,omitempty"`
+	Audience   string   `json:"aud,omitempty"`
+	ExpiresAt  int64    `json:"exp,omitempty"`
+	IssuedAt   int64    `json:"iat,omitempty"`
+}
+
+// NewIntrospectionResponse описує активний токен.
+func NewIntrospectionResponse(token *JWTToken) IntrospectionResponse {
+	return IntrospectionResponse{
+		Active:     true,
+		Subject:    fmt.Sprint(token.UserID),
+		UserID:     token.UserID,
+		Username:   token.Username,
+		Roles:      token.Roles,
+		Scope:      token.Scope,
+		ClientID:   token.ClientID,
+		SessionID:  token.SessionID,
+		OrgID:      token.OrgID,
+		AuthMethod: token.AuthMethod,
+		Issuer:     TokenIssuer,
+		Audience:   token.Audience,
+		ExpiresAt:  token.ExpiresAt,
+		IssuedAt:   token.IssuedAt,
+	}
+}
+
+// Verifier перевіряє токени SSO, кешуючи JWKS.
+type Verifier struct {
+	cfg VerifierConfig
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+
+	fetchMu sync.Mutex
+}
+
+// NewVerifier завантажує JWKS і запускає фонове оновлення, яке зупиняється разом з ctx.
+func NewVerifier(ctx context.Context, cfg VerifierConfig) (*Verifier, error) {
+	if cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("JWKS URL is required")
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = TokenIssuer
+	}
+	if cfg.RefreshInterval == 0 {
+		cfg.RefreshInterval = 15 * time.Minute
+	}
+	if cfg.MinRefetchInterval == 0 {
+		cfg.MinRefetchInterval = 30 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	v := &Verifier{cfg: cfg, keys: map[string]*rsa.PublicKey{}}
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	go v.refreshLoop(ctx)
+
+	return v, nil
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch JWKS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("invalid JWKS: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "Пропущено ключ JWKS", "kid", jwk.Kid, "err", err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *Verifier) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(v.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Якщо SSO недоступний, працюємо з попередніми ключами.
+			if err := v.refresh(ctx); err != nil {
+				slog.WarnContext(ctx, "Не вдалося оновити JWKS", "err", err.Error())
+			}
+		}
+	}
+}
+
+// key шукає ключ за kid. Невідомий kid означає ротацію ключів у SSO,
+// тож JWKS перечитується, але не частіше за MinRefetchInterval.
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	fetchedAt := v.fetchedAt
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	if time.Since(fetchedAt) < v.cfg.MinRefetchInterval {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// Verify перевіряє підпис і claims токена. Непрозорі токени перевіряються через introspection, якщо її налаштовано.
+func (v *Verifier) Verify(ctx context.Context, tokenStr string) (*JWTToken, error) {
+	if strings.Count(tokenStr, ".") != 2 {
+		if v.cfg.Introspection == nil {
+			return nil, fmt.Errorf("opaque tokens are not supported")
+		}
+		return v.introspect(ctx, tokenStr)
+	}
+
+	parser := jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg()}, SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenStr, &JWTToken{}, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*JWTToken)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	claims.AuthMethod = "jwt"
+	return claims, nil
+}
+
+func (v *Verifier) validateClaims(claims *JWTToken) error {
+	now := time.Now().Unix()
+	skew := int64(v.cfg.ClockSkew.Seconds())
+
+	switch {
+	case claims.Purpose != "":
+		return fmt.Errorf("invalid token")
+	case !claims.VerifyExpiresAt(now-skew, true):
+		return fmt.Errorf("token expired")
+	case !claims.VerifyNotBefore(now+skew, false), !claims.VerifyIssuedAt(now+skew, false):
+		return fmt.Errorf("token used before issued")
+	case !claims.VerifyIssuer(v.cfg.Issuer, true):
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	case v.cfg.Audience != "" && !claims.VerifyAudience(v.cfg.Audience, v.cfg.RequireAudience):
+		return fmt.Errorf("unexpected audience %q", claims.Audience)
+	}
+
+	return nil
+}
+
+func (v *Verifier) introspect(ctx context.Context, tokenStr string) (*JWTToken, error) {
+	form := url.Values{"token": {tokenStr}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.cfg.Introspection.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(v.cfg.Introspection.ClientID, v.cfg.Introspection.ClientSecret)
+
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspection failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection failed: status %d", resp.StatusCode)
+	}
+
+	var result IntrospectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %v", err)
+	}
+	if !result.Active {
+		return nil, fmt.Errorf("token is not active")
+	}
+
+	claims := &JWTToken{
+		Username:   result.Username,
+		UserID:     result.UserID,
+		SessionID:  result.SessionID,
+		Roles:      result.Roles,
+		ClientID:   result.ClientID,
+		Scope:      result.Scope,
+		OrgID:      result.OrgID,
+		AuthMethod: result.AuthMethod,
+	}
+	claims.Issuer = result.Issuer
+	claims.Audience = result.Audience
+	claims.ExpiresAt = result.ExpiresAt
+	claims.IssuedAt = result.IssuedAt
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Middleware — адаптер для net/http: кладе Principal у контекст або відповідає 401.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, ok := bearerToken(r)
+		if !ok {
+			errorWriter(w, r, http.StatusUnauthorized, ErrCodeUnauthorized)
+			return
+		}
+
+		token, err := v.Verify(r.Context(), tokenString)
+		if err != nil {
+			slog.DebugContext(r.Context(), "Помилка авторизації", "err", err.Error())
+			errorWriter(w, r, http.StatusUnauthorized, ErrCodeUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, withToken(r, token))
+	})
+}
+
+// MuxMiddleware — адаптер для router.Use у gorilla/mux.
+func (v *Verifier) MuxMiddleware() mux.MiddlewareFunc {
+	return v.Middleware
+}